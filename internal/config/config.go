@@ -2,9 +2,6 @@ package config
 
 import (
 	"os"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/opoccomaxao/ffmpegbox/internal/models"
@@ -52,18 +49,79 @@ type ProcessingConfig struct {
 }
 
 type FFmpegConfig struct {
-	BinaryPath           string   `yaml:"binary_path"`
-	AllowedOutputFormats []string `yaml:"allowed_output_formats"`
-	AllowedVideoCodecs   []string `yaml:"allowed_video_codecs"`
-	AllowedAudioCodecs   []string `yaml:"allowed_audio_codecs"`
-	AllowedPresets       []string `yaml:"allowed_presets"`
-	MaxResolution        string   `yaml:"max_resolution"`
-	MaxFramerate         int      `yaml:"max_framerate"`
+	BinaryPath            string              `yaml:"binary_path"`
+	AllowedOutputFormats  []string            `yaml:"allowed_output_formats"`
+	AllowedVideoCodecs    []string            `yaml:"allowed_video_codecs"`
+	AllowedAudioCodecs    []string            `yaml:"allowed_audio_codecs"`
+	AllowedPresets        []string            `yaml:"allowed_presets"`
+	AllowedSubtitleCodecs []string            `yaml:"allowed_subtitle_codecs"`
+	AllowedInputSchemes   []string            `yaml:"allowed_input_schemes"`
+	AllowedOutputSchemes  []string            `yaml:"allowed_output_schemes"`
+	MaxWidth              int                 `yaml:"max_width"`
+	MaxHeight             int                 `yaml:"max_height"`
+	MaxFramerate          int                 `yaml:"max_framerate"`
+	IdleTimeout           string              `yaml:"idle_timeout"`
+	Streaming             StreamingConfig     `yaml:"streaming"`
+	HardwareAccel         HardwareAccelConfig `yaml:"hardware_accel"`
+	RateControl           RateControlConfig   `yaml:"rate_control"`
+}
+
+// RateControlConfig gates which rate-control modes a task may request and
+// the valid CRF range per video codec.
+type RateControlConfig struct {
+	AllowedRateControls []string            `yaml:"allowed_rate_controls"`
+	CRFRanges           map[string]CRFRange `yaml:"crf_ranges"`
+}
+
+// CRFRange is the valid [Min,Max] CRF value for a given video codec.
+type CRFRange struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+// HardwareAccelConfig controls whether ffmpeg invocations may use a
+// hardware-capable encoder and which backend device to target.
+type HardwareAccelConfig struct {
+	Enabled         bool     `yaml:"enabled"`
+	Device          string   `yaml:"device"`
+	Preferred       []string `yaml:"preferred"`
+	AllowedHWAccels []string `yaml:"allowed_hw_accels"`
+	AllowHWFallback bool     `yaml:"allow_hw_fallback"`
+}
+
+// StreamingConfig bounds and defaults HLS/DASH output produced when a task's
+// OutputMode is "hls" or "dash".
+type StreamingConfig struct {
+	SegmentDuration       int                             `yaml:"segment_duration"`
+	PlaylistType          string                          `yaml:"playlist_type"`
+	MaxRenditions         int                             `yaml:"max_renditions"`
+	AllowedOutputModes    []string                        `yaml:"allowed_output_modes"`
+	SegmentDurationBounds map[string]SegmentDurationRange `yaml:"segment_duration_bounds"`
+}
+
+// SegmentDurationRange is the valid [Min,Max] segment duration in seconds
+// for a given output mode ("hls" or "dash").
+type SegmentDurationRange struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
 }
 
 type StorageConfig struct {
-	TempDir      string `yaml:"temp_dir"`
-	DatabasePath string `yaml:"database_path"`
+	Backend      string   `yaml:"backend"`
+	TempDir      string   `yaml:"temp_dir"`
+	DatabasePath string   `yaml:"database_path"`
+	S3           S3Config `yaml:"s3"`
+}
+
+// S3Config configures the S3-compatible backend used when
+// StorageConfig.Backend is "s3".
+type S3Config struct {
+	Endpoint     string `yaml:"endpoint"`
+	Bucket       string `yaml:"bucket"`
+	Region       string `yaml:"region"`
+	AccessKey    string `yaml:"access_key"`
+	SecretKey    string `yaml:"secret_key"`
+	UsePathStyle bool   `yaml:"use_path_style"`
 }
 
 type LoggingConfig struct {
@@ -223,16 +281,157 @@ func (f *FFmpegConfig) Validate() error {
 		return errors.Wrap(models.ErrInvalidParameter, "allowed_presets cannot be empty")
 	}
 
-	if !isValidResolution(f.MaxResolution) {
+	if f.MaxWidth < 1 {
+		return errors.Wrapf(models.ErrInvalidParameter, "max_width must be at least 1, got %d", f.MaxWidth)
+	}
+
+	if f.MaxHeight < 1 {
+		return errors.Wrapf(models.ErrInvalidParameter, "max_height must be at least 1, got %d", f.MaxHeight)
+	}
+
+	if f.MaxFramerate < 1 || f.MaxFramerate > 240 {
+		return errors.Wrapf(models.ErrInvalidParameter, "max_framerate must be 1-240, got %d", f.MaxFramerate)
+	}
+
+	if _, err := time.ParseDuration(f.IdleTimeout); err != nil {
+		return errors.Wrap(err, "invalid idle_timeout")
+	}
+
+	if err := f.Streaming.Validate(); err != nil {
+		return errors.Wrap(err, "streaming config")
+	}
+
+	if err := f.HardwareAccel.Validate(); err != nil {
+		return errors.Wrap(err, "hardware_accel config")
+	}
+
+	if err := f.RateControl.Validate(); err != nil {
+		return errors.Wrap(err, "rate_control config")
+	}
+
+	return nil
+}
+
+func (r *RateControlConfig) Validate() error {
+	validModes := map[string]bool{
+		"cbr":      true,
+		"vbr":      true,
+		"crf":      true,
+		"cq":       true,
+		"two-pass": true,
+	}
+
+	for _, mode := range r.AllowedRateControls {
+		if !validModes[mode] {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"invalid rate control mode %q, must be one of: cbr, vbr, crf, cq, two-pass",
+				mode,
+			)
+		}
+	}
+
+	for codec, rng := range r.CRFRanges {
+		if rng.Min < 0 || rng.Max < rng.Min {
+			return errors.Wrapf(models.ErrInvalidParameter, "invalid crf_ranges for codec %q: %d-%d", codec, rng.Min, rng.Max)
+		}
+	}
+
+	return nil
+}
+
+func (h *HardwareAccelConfig) Validate() error {
+	if !h.Enabled {
+		return nil
+	}
+
+	if len(h.Preferred) == 0 {
+		return errors.Wrap(models.ErrInvalidParameter, "hardware acceleration is enabled but preferred is empty")
+	}
+
+	validBackends := map[string]bool{
+		"vaapi":        true,
+		"nvenc":        true,
+		"qsv":          true,
+		"videotoolbox": true,
+		"none":         true,
+	}
+
+	for _, backend := range h.Preferred {
+		if !validBackends[backend] {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"invalid preferred hardware backend %q, must be one of: vaapi, nvenc, qsv, videotoolbox, none",
+				backend,
+			)
+		}
+	}
+
+	for _, backend := range h.AllowedHWAccels {
+		if !validBackends[backend] {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"invalid allowed_hw_accels backend %q, must be one of: vaapi, nvenc, qsv, videotoolbox, none",
+				backend,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (s *StreamingConfig) Validate() error {
+	if s.SegmentDuration < 1 {
+		return errors.Wrap(models.ErrInvalidParameter, "segment_duration must be >= 1")
+	}
+
+	validPlaylistTypes := map[string]bool{
+		"vod":   true,
+		"event": true,
+	}
+
+	if !validPlaylistTypes[s.PlaylistType] {
 		return errors.Wrapf(
 			models.ErrInvalidParameter,
-			"invalid max_resolution format: %q (expected WIDTHxHEIGHT)",
-			f.MaxResolution,
+			"invalid playlist_type %q, must be one of: vod, event",
+			s.PlaylistType,
 		)
 	}
 
-	if f.MaxFramerate < 1 || f.MaxFramerate > 240 {
-		return errors.Wrapf(models.ErrInvalidParameter, "max_framerate must be 1-240, got %d", f.MaxFramerate)
+	if s.MaxRenditions < 1 {
+		return errors.Wrap(models.ErrInvalidParameter, "max_renditions must be >= 1")
+	}
+
+	validOutputModes := map[string]bool{
+		"file": true,
+		"hls":  true,
+		"dash": true,
+	}
+
+	for _, mode := range s.AllowedOutputModes {
+		if !validOutputModes[mode] {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"invalid allowed_output_mode %q, must be one of: file, hls, dash",
+				mode,
+			)
+		}
+	}
+
+	for mode, bounds := range s.SegmentDurationBounds {
+		if !validOutputModes[mode] || mode == "file" {
+			return errors.Wrapf(models.ErrInvalidParameter, "invalid segment_duration_bounds mode %q", mode)
+		}
+
+		if bounds.Min < 1 || bounds.Max < bounds.Min {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"invalid segment_duration_bounds for mode %q: %d-%d",
+				mode,
+				bounds.Min,
+				bounds.Max,
+			)
+		}
 	}
 
 	return nil
@@ -247,6 +446,33 @@ func (s *StorageConfig) Validate() error {
 		return errors.Wrap(models.ErrInvalidParameter, "database_path cannot be empty")
 	}
 
+	switch s.Backend {
+	case "", "local":
+		return nil
+	case "s3":
+		return s.S3.Validate()
+	default:
+		return errors.Wrapf(models.ErrInvalidParameter, "invalid storage backend %q, must be one of: local, s3", s.Backend)
+	}
+}
+
+func (s *S3Config) Validate() error {
+	if s.Bucket == "" {
+		return errors.Wrap(models.ErrInvalidParameter, "s3 bucket cannot be empty")
+	}
+
+	if s.Region == "" {
+		return errors.Wrap(models.ErrInvalidParameter, "s3 region cannot be empty")
+	}
+
+	if s.AccessKey == "" {
+		return errors.Wrap(models.ErrInvalidParameter, "s3 access_key cannot be empty")
+	}
+
+	if s.SecretKey == "" {
+		return errors.Wrap(models.ErrInvalidParameter, "s3 secret_key cannot be empty")
+	}
+
 	return nil
 }
 
@@ -320,45 +546,8 @@ func (p *ProcessingConfig) GetCleanupAge() time.Duration {
 	return d
 }
 
-func ParseResolution(resolution string) (int, int, error) {
-	if !isValidResolution(resolution) {
-		return 0, 0, errors.Wrapf(
-			models.ErrInvalidParameter,
-			"invalid resolution format: %q (expected WIDTHxHEIGHT)",
-			resolution,
-		)
-	}
-
-	parts := strings.Split(resolution, "x")
-
-	width, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, 0, errors.Wrap(err, "invalid width in resolution")
-	}
-
-	height, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return 0, 0, errors.Wrap(err, "invalid height in resolution")
-	}
-
-	if width < 1 || height < 1 {
-		return 0, 0, errors.Wrap(models.ErrInvalidParameter, "resolution dimensions must be positive")
-	}
-
-	return width, height, nil
-}
-
-func isValidResolution(resolution string) bool {
-	matched, _ := regexp.MatchString(`^\d+x\d+$`, resolution)
+func (f *FFmpegConfig) GetIdleTimeout() time.Duration {
+	d, _ := time.ParseDuration(f.IdleTimeout)
 
-	return matched
-}
-
-func (f *FFmpegConfig) GetMaxResolutionPixels() (int, error) {
-	width, height, err := ParseResolution(f.MaxResolution)
-	if err != nil {
-		return 0, err
-	}
-
-	return width * height, nil
+	return d
 }
@@ -0,0 +1,237 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"github.com/pkg/errors"
+)
+
+// hwVideoCodecs maps a hardware-capable ffmpeg video encoder to the hwaccel
+// backend it requires.
+var hwVideoCodecs = map[string]string{
+	"h264_vaapi":        "vaapi",
+	"hevc_vaapi":        "vaapi",
+	"h264_nvenc":        "nvenc",
+	"hevc_nvenc":        "nvenc",
+	"h264_qsv":          "qsv",
+	"hevc_qsv":          "qsv",
+	"h264_videotoolbox": "videotoolbox",
+	"hevc_videotoolbox": "videotoolbox",
+}
+
+var encoderLineRe = regexp.MustCompile(`^\s*[VAS\.][F\.][S\.][X\.][B\.][D\.]\s+(\S+)\s`)
+
+// Capabilities reports the hwaccel backends and encoders ffmpeg supports on
+// the host, as detected by Service.DetectCapabilities.
+type Capabilities struct {
+	HWAccels []string
+	Encoders []string
+}
+
+// DetectCapabilities probes `ffmpeg -hwaccels` and `ffmpeg -encoders` and
+// caches the result on the Service. It should be called once at startup.
+func (s *Service) DetectCapabilities(ctx context.Context) error {
+	hwaccels, err := s.runHWAccelsProbe(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to detect hwaccels")
+	}
+
+	encoders, err := s.runEncodersProbe(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to detect encoders")
+	}
+
+	s.capsMu.Lock()
+	s.caps = Capabilities{HWAccels: hwaccels, Encoders: encoders}
+	s.capsMu.Unlock()
+
+	return nil
+}
+
+// Capabilities returns the most recently detected hwaccel/encoder support.
+// It is empty until DetectCapabilities has been called.
+func (s *Service) Capabilities() Capabilities {
+	s.capsMu.RLock()
+	defer s.capsMu.RUnlock()
+
+	return s.caps
+}
+
+func (s *Service) runHWAccelsProbe(ctx context.Context) ([]string, error) {
+	// #nosec G204 -- Binary path is from config, -hwaccels is a safe static argument
+	cmd := exec.CommandContext(ctx, s.cfg.BinaryPath, "-hwaccels")
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var hwaccels []string
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+
+		hwaccels = append(hwaccels, line)
+	}
+
+	return hwaccels, nil
+}
+
+func (s *Service) runEncodersProbe(ctx context.Context) ([]string, error) {
+	// #nosec G204 -- Binary path is from config, -encoders is a safe static argument
+	cmd := exec.CommandContext(ctx, s.cfg.BinaryPath, "-encoders")
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var encoders []string
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		if m := encoderLineRe.FindStringSubmatch(scanner.Text()); m != nil {
+			encoders = append(encoders, m[1])
+		}
+	}
+
+	return encoders, nil
+}
+
+// hwBackendFor returns the hwaccel backend a video codec requires, and
+// whether it requires one at all.
+func hwBackendFor(videoCodec string) (string, bool) {
+	backend, ok := hwVideoCodecs[videoCodec]
+
+	return backend, ok
+}
+
+// hwaccelArgs returns the -hwaccel (and related) arguments that must be
+// placed before -i for the given backend.
+func hwaccelArgs(backend, device string) []string {
+	switch backend {
+	case "vaapi":
+		args := []string{"-hwaccel", "vaapi"}
+		if device != "" {
+			args = append(args, "-vaapi_device", device)
+		}
+
+		return args
+	case "nvenc":
+		return []string{"-hwaccel", "cuda", "-hwaccel_output_format", "cuda"}
+	case "qsv":
+		return []string{"-hwaccel", "qsv"}
+	case "videotoolbox":
+		return []string{"-hwaccel", "videotoolbox"}
+	default:
+		return nil
+	}
+}
+
+// hwUploadFilter returns the -vf filter chain needed to get decoded frames
+// onto the hardware surface for the given backend, if any.
+func hwUploadFilter(backend string) string {
+	if backend == "vaapi" {
+		return "format=nv12,hwupload"
+	}
+
+	return ""
+}
+
+func (s *Service) validateHWAccel(task *models.Task) error {
+	if backend, ok := hwBackendFor(task.VideoCodec); ok {
+		if err := s.checkHWBackendAvailable(backend); err != nil {
+			return errors.Wrapf(err, "video codec %q", task.VideoCodec)
+		}
+	}
+
+	if task.HWAccel == "" {
+		return nil
+	}
+
+	if !s.isAllowedHWAccel(task.HWAccel) {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"hw_accel %q not allowed. Allowed: %v",
+			task.HWAccel,
+			s.cfg.HardwareAccel.AllowedHWAccels,
+		)
+	}
+
+	if err := s.checkHWBackendAvailable(task.HWAccel); err != nil && !s.cfg.HardwareAccel.AllowHWFallback {
+		return err
+	}
+
+	return nil
+}
+
+// checkHWBackendAvailable reports whether backend is enabled in config and
+// was detected as available by DetectCapabilities.
+func (s *Service) checkHWBackendAvailable(backend string) error {
+	if !s.cfg.HardwareAccel.Enabled {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"hwaccel backend %q requires hardware acceleration, which is disabled",
+			backend,
+		)
+	}
+
+	if !slices.Contains(s.Capabilities().HWAccels, backend) {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"hwaccel backend %q is not available",
+			backend,
+		)
+	}
+
+	return nil
+}
+
+// isAllowedHWAccel reports whether backend is permitted. An empty
+// AllowedHWAccels list means the restriction isn't configured, so every
+// backend is allowed.
+func (s *Service) isAllowedHWAccel(backend string) bool {
+	if len(s.cfg.HardwareAccel.AllowedHWAccels) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.cfg.HardwareAccel.AllowedHWAccels, backend)
+}
+
+// hwCodecByBackend is the inverse of hwVideoCodecs: given a backend and a
+// software codec, it names the hardware-capable encoder to swap in.
+var hwCodecByBackend = map[string]map[string]string{
+	"vaapi":        {"libx264": "h264_vaapi", "libx265": "hevc_vaapi"},
+	"nvenc":        {"libx264": "h264_nvenc", "libx265": "hevc_nvenc"},
+	"qsv":          {"libx264": "h264_qsv", "libx265": "hevc_qsv"},
+	"videotoolbox": {"libx264": "h264_videotoolbox", "libx265": "hevc_videotoolbox"},
+}
+
+// ResolveVideoCodec decides the concrete ffmpeg video codec for task. When
+// task.PreferHW requests a hardware backend via task.HWAccel and that
+// backend is enabled and available, the matching hardware encoder is used;
+// otherwise the task's software codec is kept. Either way the decision is
+// recorded on task.ActualVideoCodec so callers can see what actually ran.
+func (s *Service) ResolveVideoCodec(task *models.Task) string {
+	codec := task.VideoCodec
+
+	if task.PreferHW && task.HWAccel != "" {
+		if hwCodec, ok := hwCodecByBackend[task.HWAccel][task.VideoCodec]; ok && s.checkHWBackendAvailable(task.HWAccel) == nil {
+			codec = hwCodec
+		}
+	}
+
+	task.ActualVideoCodec = codec
+
+	return codec
+}
@@ -0,0 +1,160 @@
+package ffmpeg
+
+import (
+	"testing"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/config"
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+)
+
+func TestBuildCommandArgsVAAPI(t *testing.T) {
+	task := &models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "h264_vaapi",
+	}
+
+	hwCfg := config.HardwareAccelConfig{
+		Enabled: true,
+		Device:  "/dev/dri/renderD128",
+	}
+
+	got := buildCommandArgs("/tmp/input.mp4", "/tmp/output.mp4", task, task.VideoCodec, config.StreamingConfig{}, hwCfg)
+
+	want := []string{
+		"-hwaccel", "vaapi",
+		"-vaapi_device", "/dev/dri/renderD128",
+		"-i", "/tmp/input.mp4",
+		"-vf", "format=nv12,hwupload",
+		"-c:v", "h264_vaapi",
+		"-f", "mp4",
+		"-y",
+		"/tmp/output.mp4",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("buildCommandArgs() length = %d, want %d\nGot:  %v\nWant: %v", len(got), len(want), got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("buildCommandArgs()[%d] = %q, want %q\nGot:  %v\nWant: %v", i, got[i], want[i], got, want)
+		}
+	}
+}
+
+func TestValidateHWAccelRejectsWhenDisabled(t *testing.T) {
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"h264_vaapi"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"medium"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+	}
+
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+
+	err := svc.validateHWAccel(&models.Task{VideoCodec: "h264_vaapi"})
+	if err == nil {
+		t.Fatal("expected error when hardware acceleration is disabled")
+	}
+}
+
+func TestValidateHWAccelRejectsUnavailableBackend(t *testing.T) {
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"h264_vaapi"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"medium"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+		HardwareAccel: config.HardwareAccelConfig{
+			Enabled:   true,
+			Preferred: []string{"vaapi"},
+		},
+	}
+
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+
+	err := svc.validateHWAccel(&models.Task{VideoCodec: "h264_vaapi"})
+	if err == nil {
+		t.Fatal("expected error when hwaccel backend was not detected as available")
+	}
+}
+
+func newPreferHWService(t *testing.T, allowFallback bool) *Service {
+	t.Helper()
+
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"libx264"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"medium"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+		HardwareAccel: config.HardwareAccelConfig{
+			Enabled:         true,
+			Preferred:       []string{"vaapi"},
+			AllowHWFallback: allowFallback,
+		},
+	}
+
+	return NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+}
+
+func TestValidateHWAccelFallsBackWhenAllowed(t *testing.T) {
+	svc := newPreferHWService(t, true)
+
+	task := &models.Task{VideoCodec: "libx264", HWAccel: "vaapi", PreferHW: true}
+	if err := svc.validateHWAccel(task); err != nil {
+		t.Fatalf("expected fallback to be permitted, got %v", err)
+	}
+}
+
+func TestValidateHWAccelRejectsWithoutFallback(t *testing.T) {
+	svc := newPreferHWService(t, false)
+
+	task := &models.Task{VideoCodec: "libx264", HWAccel: "vaapi", PreferHW: true}
+	if err := svc.validateHWAccel(task); err == nil {
+		t.Fatal("expected error when hwaccel is unavailable and fallback is disallowed")
+	}
+}
+
+func TestResolveVideoCodecFallsBackToSoftware(t *testing.T) {
+	svc := newPreferHWService(t, true)
+
+	task := &models.Task{VideoCodec: "libx264", HWAccel: "vaapi", PreferHW: true}
+
+	got := svc.ResolveVideoCodec(task)
+	if got != "libx264" {
+		t.Errorf("ResolveVideoCodec() = %q, want %q (vaapi not detected as available)", got, "libx264")
+	}
+
+	if task.ActualVideoCodec != "libx264" {
+		t.Errorf("task.ActualVideoCodec = %q, want %q", task.ActualVideoCodec, "libx264")
+	}
+}
+
+func TestResolveVideoCodecSwapsToHardwareWhenAvailable(t *testing.T) {
+	svc := newPreferHWService(t, true)
+	svc.capsMu.Lock()
+	svc.caps = Capabilities{HWAccels: []string{"vaapi"}}
+	svc.capsMu.Unlock()
+
+	task := &models.Task{VideoCodec: "libx264", HWAccel: "vaapi", PreferHW: true}
+
+	got := svc.ResolveVideoCodec(task)
+	if got != "h264_vaapi" {
+		t.Errorf("ResolveVideoCodec() = %q, want %q", got, "h264_vaapi")
+	}
+
+	if task.ActualVideoCodec != "h264_vaapi" {
+		t.Errorf("task.ActualVideoCodec = %q, want %q", task.ActualVideoCodec, "h264_vaapi")
+	}
+}
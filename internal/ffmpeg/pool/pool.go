@@ -0,0 +1,318 @@
+// Package pool provides a bounded-concurrency worker pool for running
+// ffmpeg commands, with per-client fair scheduling, per-client concurrency
+// caps, and backpressure.
+package pool
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQueueFull is returned by Submit when the pool's submission queue has
+// reached its configured capacity. Callers (e.g. HTTP handlers) should treat
+// this as a request to back off rather than blocking indefinitely.
+var ErrQueueFull = errors.New("task queue is full")
+
+// Result is one line of ffmpeg progress output, or the final outcome of a
+// job when Done is true.
+type Result struct {
+	Line string
+	Err  error
+	Done bool
+}
+
+// CommandFunc builds the *exec.Cmd to run for a submitted job. It is called
+// from the worker goroutine that picks up the job, not from Submit.
+type CommandFunc func(ctx context.Context) *exec.Cmd
+
+type job struct {
+	ctx        context.Context
+	clientName string
+	buildCmd   CommandFunc
+	hooks      Hooks
+	results    chan Result
+}
+
+// Hooks are optional callbacks a caller can use to observe a job's process
+// without the pool needing to know about liveness tracking, metrics, etc.
+type Hooks struct {
+	// OnStart is called once the job's process has started.
+	OnStart func(cmd *exec.Cmd)
+	// OnLine is called for every line of stderr output the process emits.
+	OnLine func(line string)
+	// OnDone is called once the job's process has exited, successfully or not.
+	OnDone func()
+}
+
+// Pool runs ffmpeg commands across a fixed number of worker goroutines,
+// drawing from per-client queues in round-robin order so a single client
+// cannot starve the others.
+type Pool struct {
+	queueSize int
+
+	mu            sync.Mutex
+	clientQueues  map[string][]*job
+	clientOrder   []string
+	rrIndex       int
+	pending       int
+	running       map[*exec.Cmd]struct{}
+	clientLimits  map[string]int
+	clientRunning map[string]int
+
+	wake     chan struct{}
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New starts a Pool with workerCount worker goroutines and a submission
+// queue bounded to queueSize pending jobs.
+func New(workerCount, queueSize int) *Pool {
+	p := &Pool{
+		queueSize:     queueSize,
+		clientQueues:  make(map[string][]*job),
+		running:       make(map[*exec.Cmd]struct{}),
+		clientLimits:  make(map[string]int),
+		clientRunning: make(map[string]int),
+		wake:          make(chan struct{}, 1),
+		shutdown:      make(chan struct{}),
+	}
+
+	for range workerCount {
+		p.wg.Add(1)
+
+		go p.workerLoop()
+	}
+
+	return p
+}
+
+// Submit enqueues a job for clientName and returns a channel of progress
+// results. It returns ErrQueueFull if the pool is already at capacity.
+func (p *Pool) Submit(ctx context.Context, clientName string, buildCmd CommandFunc, hooks Hooks) (<-chan Result, error) {
+	p.mu.Lock()
+
+	if p.pending >= p.queueSize {
+		p.mu.Unlock()
+
+		return nil, ErrQueueFull
+	}
+
+	results := make(chan Result, 8)
+	j := &job{ctx: ctx, clientName: clientName, buildCmd: buildCmd, hooks: hooks, results: results}
+
+	if _, ok := p.clientQueues[clientName]; !ok {
+		p.clientOrder = append(p.clientOrder, clientName)
+	}
+
+	p.clientQueues[clientName] = append(p.clientQueues[clientName], j)
+	p.pending++
+
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+
+	return results, nil
+}
+
+// SetClientLimit caps the number of jobs submitted by clientName that may
+// run concurrently across the pool's workers, independent of the global
+// worker count. A max of 0 (or negative) removes the cap, leaving the
+// global worker count as the only limit for that client.
+func (p *Pool) SetClientLimit(clientName string, max int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if max <= 0 {
+		delete(p.clientLimits, clientName)
+
+		return
+	}
+
+	p.clientLimits[clientName] = max
+}
+
+// Shutdown stops accepting new work from the queue and waits for in-flight
+// ffmpeg processes to finish, killing any that are still running once
+// deadline elapses.
+func (p *Pool) Shutdown(deadline time.Duration) {
+	close(p.shutdown)
+
+	done := make(chan struct{})
+
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(deadline):
+	}
+
+	p.mu.Lock()
+	for cmd := range p.running {
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+	}
+	p.mu.Unlock()
+
+	<-done
+}
+
+func (p *Pool) workerLoop() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.shutdown:
+			return
+		default:
+		}
+
+		j := p.nextJob()
+		if j == nil {
+			select {
+			case <-p.wake:
+			case <-p.shutdown:
+				return
+			}
+
+			continue
+		}
+
+		p.run(j)
+	}
+}
+
+func (p *Pool) nextJob() *job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for range p.clientOrder {
+		if len(p.clientOrder) == 0 {
+			return nil
+		}
+
+		name := p.clientOrder[p.rrIndex%len(p.clientOrder)]
+
+		queue := p.clientQueues[name]
+		if len(queue) == 0 {
+			p.removeClientLocked(name)
+
+			continue
+		}
+
+		if limit, ok := p.clientLimits[name]; ok && p.clientRunning[name] >= limit {
+			p.rrIndex++
+
+			continue
+		}
+
+		j := queue[0]
+		p.clientQueues[name] = queue[1:]
+		p.pending--
+		p.rrIndex++
+		p.clientRunning[name]++
+
+		if len(p.clientQueues[name]) == 0 {
+			p.removeClientLocked(name)
+		}
+
+		return j
+	}
+
+	return nil
+}
+
+// releaseClientSlot returns the concurrency slot a dequeued job held for
+// clientName, once that job's process has finished, and wakes a worker in
+// case a queued job for clientName was waiting on that slot.
+func (p *Pool) releaseClientSlot(clientName string) {
+	p.mu.Lock()
+
+	if p.clientRunning[clientName] <= 1 {
+		delete(p.clientRunning, clientName)
+	} else {
+		p.clientRunning[clientName]--
+	}
+
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Pool) removeClientLocked(name string) {
+	delete(p.clientQueues, name)
+
+	for i, n := range p.clientOrder {
+		if n == name {
+			p.clientOrder = append(p.clientOrder[:i], p.clientOrder[i+1:]...)
+
+			break
+		}
+	}
+}
+
+func (p *Pool) run(j *job) {
+	defer close(j.results)
+	defer p.releaseClientSlot(j.clientName)
+
+	cmd := j.buildCmd(j.ctx)
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		j.results <- Result{Err: errors.Wrap(err, "failed to attach stderr pipe"), Done: true}
+
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		j.results <- Result{Err: errors.Wrap(err, "failed to start ffmpeg"), Done: true}
+
+		return
+	}
+
+	p.mu.Lock()
+	p.running[cmd] = struct{}{}
+	p.mu.Unlock()
+
+	if j.hooks.OnStart != nil {
+		j.hooks.OnStart(cmd)
+	}
+
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if j.hooks.OnLine != nil {
+			j.hooks.OnLine(line)
+		}
+
+		j.results <- Result{Line: line}
+	}
+
+	waitErr := cmd.Wait()
+
+	p.mu.Lock()
+	delete(p.running, cmd)
+	p.mu.Unlock()
+
+	if j.hooks.OnDone != nil {
+		j.hooks.OnDone()
+	}
+
+	j.results <- Result{Err: waitErr, Done: true}
+}
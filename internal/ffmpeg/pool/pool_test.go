@@ -0,0 +1,111 @@
+package pool
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolSubmitRunsJob(t *testing.T) {
+	p := New(1, 4)
+
+	results, err := p.Submit(context.Background(), "client-a", func(ctx context.Context) *exec.Cmd {
+		return exec.CommandContext(ctx, "echo", "frame=1")
+	}, Hooks{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	var sawDone bool
+
+	for r := range results {
+		if r.Done {
+			sawDone = true
+
+			if r.Err != nil {
+				t.Errorf("unexpected job error: %v", r.Err)
+			}
+		}
+	}
+
+	if !sawDone {
+		t.Error("expected a final Result with Done=true")
+	}
+}
+
+func TestPoolSubmitErrQueueFull(t *testing.T) {
+	p := New(0, 1)
+
+	buildCmd := func(ctx context.Context) *exec.Cmd {
+		return exec.CommandContext(ctx, "sleep", "1")
+	}
+
+	if _, err := p.Submit(context.Background(), "client-a", buildCmd, Hooks{}); err != nil {
+		t.Fatalf("first Submit() error = %v", err)
+	}
+
+	if _, err := p.Submit(context.Background(), "client-a", buildCmd, Hooks{}); err != ErrQueueFull {
+		t.Errorf("second Submit() error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestPoolShutdownWaitsForWorkers(t *testing.T) {
+	p := New(1, 4)
+	p.Shutdown(time.Second)
+}
+
+func TestPoolSetClientLimitCapsPerClientConcurrency(t *testing.T) {
+	p := New(4, 8)
+	p.SetClientLimit("client-a", 1)
+
+	var (
+		mu         sync.Mutex
+		running    int
+		maxRunning int
+	)
+
+	hooks := Hooks{
+		OnStart: func(cmd *exec.Cmd) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+		},
+		OnDone: func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		},
+	}
+
+	buildCmd := func(ctx context.Context) *exec.Cmd {
+		return exec.CommandContext(ctx, "sleep", "0.2")
+	}
+
+	channels := make([]<-chan Result, 0, 3)
+
+	for range 3 {
+		results, err := p.Submit(context.Background(), "client-a", buildCmd, hooks)
+		if err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+
+		channels = append(channels, results)
+	}
+
+	for _, results := range channels {
+		for range results {
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if maxRunning > 1 {
+		t.Errorf("observed %d concurrent jobs for client-a, want at most 1 per SetClientLimit", maxRunning)
+	}
+}
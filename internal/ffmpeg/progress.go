@@ -0,0 +1,133 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"github.com/pkg/errors"
+)
+
+// ProgressUpdate is one value sent on the channel returned by Service.Run:
+// either a parsed progress snapshot, or the final result once the process
+// exits, with Done set and Err carrying any failure.
+type ProgressUpdate struct {
+	Progress models.Progress
+	Err      error
+	Done     bool
+}
+
+// progressBlock accumulates the key=value lines ffmpeg emits between two
+// "progress=" markers on its -progress pipe.
+type progressBlock struct {
+	fps       float64
+	outTimeUs int64
+	speed     float64
+}
+
+func (b *progressBlock) set(key, value string) {
+	switch key {
+	case "fps":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			b.fps = v
+		}
+	case "out_time_us":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			b.outTimeUs = v
+		}
+	case "speed":
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64); err == nil {
+			b.speed = v
+		}
+	}
+}
+
+// toProgress converts the accumulated block into a models.Progress,
+// computing PercentComplete and ETA against totalDuration when it's known.
+func (b *progressBlock) toProgress(totalDuration time.Duration) models.Progress {
+	elapsed := time.Duration(b.outTimeUs) * time.Microsecond
+
+	progress := models.Progress{
+		Speed:      b.speed,
+		CurrentFPS: b.fps,
+	}
+
+	if totalDuration <= 0 {
+		return progress
+	}
+
+	progress.PercentComplete = float64(elapsed) / float64(totalDuration) * 100
+
+	if remaining := totalDuration - elapsed; remaining > 0 && b.speed > 0 {
+		progress.ETA = time.Duration(float64(remaining) / b.speed).Round(time.Second).String()
+	}
+
+	return progress
+}
+
+// Run starts task's ffmpeg command with "-progress pipe:1 -nostats"
+// prepended and streams parsed progress snapshots on the returned channel
+// until the process exits. totalDuration is the source duration (typically
+// obtained via ffprobe by the caller) used to compute PercentComplete and
+// ETA; pass 0 if unknown. The channel receives a final ProgressUpdate with
+// Done set, carrying any error the process exited with, and is then closed.
+// This method MUST only be called after ValidateTask has succeeded.
+func (s *Service) Run(
+	ctx context.Context,
+	task *models.Task,
+	inputPath, outputPath string,
+	totalDuration time.Duration,
+) (<-chan ProgressUpdate, error) {
+	videoCodec := s.ResolveVideoCodec(task)
+	args := buildCommandArgs(inputPath, outputPath, task, videoCodec, s.cfg.Streaming, s.cfg.HardwareAccel)
+	args = append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+
+	// #nosec G204 -- All arguments are validated against whitelists before reaching this point
+	cmd := exec.CommandContext(ctx, s.cfg.BinaryPath, args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to attach stdout pipe")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "failed to start ffmpeg")
+	}
+
+	updates := make(chan ProgressUpdate)
+
+	go func() {
+		defer close(updates)
+
+		block := &progressBlock{}
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), "=")
+			if !ok {
+				continue
+			}
+
+			key = strings.TrimSpace(key)
+			value = strings.TrimSpace(value)
+
+			if key == "progress" {
+				updates <- ProgressUpdate{Progress: block.toProgress(totalDuration)}
+
+				block = &progressBlock{}
+
+				continue
+			}
+
+			block.set(key, value)
+		}
+
+		updates <- ProgressUpdate{Err: cmd.Wait(), Done: true}
+	}()
+
+	return updates, nil
+}
@@ -0,0 +1,47 @@
+package ffmpeg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressBlockToProgress(t *testing.T) {
+	block := &progressBlock{}
+	block.set("fps", "29.97")
+	block.set("out_time_us", "5000000")
+	block.set("speed", "2.5x")
+
+	got := block.toProgress(10 * time.Second)
+
+	if got.CurrentFPS != 29.97 {
+		t.Errorf("CurrentFPS = %v, want 29.97", got.CurrentFPS)
+	}
+
+	if got.Speed != 2.5 {
+		t.Errorf("Speed = %v, want 2.5", got.Speed)
+	}
+
+	if got.PercentComplete != 50 {
+		t.Errorf("PercentComplete = %v, want 50", got.PercentComplete)
+	}
+
+	if got.ETA != (2 * time.Second).String() {
+		t.Errorf("ETA = %q, want %q", got.ETA, (2 * time.Second).String())
+	}
+}
+
+func TestProgressBlockToProgressWithoutTotalDuration(t *testing.T) {
+	block := &progressBlock{}
+	block.set("out_time_us", "5000000")
+	block.set("speed", "1.0x")
+
+	got := block.toProgress(0)
+
+	if got.PercentComplete != 0 {
+		t.Errorf("PercentComplete = %v, want 0 when totalDuration is unknown", got.PercentComplete)
+	}
+
+	if got.ETA != "" {
+		t.Errorf("ETA = %q, want empty when totalDuration is unknown", got.ETA)
+	}
+}
@@ -0,0 +1,200 @@
+package ffmpeg
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// progressLineRe matches the key=value tokens ffmpeg prints to stderr while
+// encoding, e.g. "frame=  120 fps= 30 q=28.0 size=...  time=00:00:04.00
+// bitrate=... speed=1.01x".
+var progressLineRe = regexp.MustCompile(`(frame|time|speed)=\s*(\S+)`)
+
+// TaskProgress is the last observed liveness signal for a running task.
+type TaskProgress struct {
+	Frame        int
+	Time         string
+	Speed        float64
+	LastObserved time.Time
+}
+
+type trackedTask struct {
+	cmd      *exec.Cmd
+	started  time.Time
+	progress TaskProgress
+}
+
+// Reaper kills ffmpeg processes that exceed a task timeout or stop reporting
+// progress for longer than an idle window, which would otherwise pin a
+// worker slot forever.
+type Reaper struct {
+	taskTimeout time.Duration
+	idleTimeout time.Duration
+	interval    time.Duration
+	onKill      func(taskID string, reason error)
+
+	mu    sync.Mutex
+	tasks map[string]*trackedTask
+}
+
+// NewReaper builds a Reaper. onKill is invoked (from the reaper's own
+// goroutine) whenever a tracked task is killed, so the caller can update the
+// task's status to StatusFailed with a descriptive ErrorMessage.
+func NewReaper(taskTimeout, idleTimeout, interval time.Duration, onKill func(taskID string, reason error)) *Reaper {
+	return &Reaper{
+		taskTimeout: taskTimeout,
+		idleTimeout: idleTimeout,
+		interval:    interval,
+		onKill:      onKill,
+		tasks:       make(map[string]*trackedTask),
+	}
+}
+
+// SetOnKill replaces the handler invoked whenever a tracked task is killed.
+func (r *Reaper) SetOnKill(onKill func(taskID string, reason error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onKill = onKill
+}
+
+// Track registers cmd as the running process for taskID so the reaper can
+// observe its progress and kill it if it hangs.
+func (r *Reaper) Track(taskID string, cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tasks[taskID] = &trackedTask{
+		cmd:     cmd,
+		started: time.Now(),
+	}
+}
+
+// Untrack stops tracking taskID, typically once the process has exited on
+// its own.
+func (r *Reaper) Untrack(taskID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tasks, taskID)
+}
+
+// ObserveProgress feeds one line of ffmpeg stderr output for taskID,
+// refreshing its liveness timestamp whenever the line carries frame/time/
+// speed tokens.
+func (r *Reaper) ObserveProgress(taskID, line string) {
+	matches := progressLineRe.FindAllStringSubmatch(line, -1)
+	if len(matches) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tasks[taskID]
+	if !ok {
+		return
+	}
+
+	for _, m := range matches {
+		switch m[1] {
+		case "frame":
+			if frame, err := strconv.Atoi(m[2]); err == nil {
+				t.progress.Frame = frame
+			}
+		case "time":
+			t.progress.Time = m[2]
+		case "speed":
+			if speed, err := strconv.ParseFloat(strings.TrimSuffix(m[2], "x"), 64); err == nil {
+				t.progress.Speed = speed
+			}
+		}
+	}
+
+	t.progress.LastObserved = time.Now()
+}
+
+// Metrics returns a snapshot of per-task progress for every currently
+// tracked task, for the API layer to surface.
+func (r *Reaper) Metrics() map[string]TaskProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[string]TaskProgress, len(r.tasks))
+	for taskID, t := range r.tasks {
+		out[taskID] = t.progress
+	}
+
+	return out
+}
+
+// Run ticks until ctx is canceled, killing any tracked process that has
+// exceeded the task timeout or gone idle for longer than the idle timeout.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reapOnce()
+		}
+	}
+}
+
+func (r *Reaper) reapOnce() {
+	now := time.Now()
+
+	r.mu.Lock()
+	toKill := make(map[string]error)
+
+	for taskID, t := range r.tasks {
+		lastSeen := t.progress.LastObserved
+		if lastSeen.IsZero() {
+			lastSeen = t.started
+		}
+
+		switch {
+		case r.taskTimeout > 0 && now.Sub(t.started) > r.taskTimeout:
+			toKill[taskID] = errors.Errorf("ffmpeg task exceeded timeout of %s", r.taskTimeout)
+		case r.idleTimeout > 0 && now.Sub(lastSeen) > r.idleTimeout:
+			toKill[taskID] = errors.Errorf("ffmpeg task produced no progress for %s", r.idleTimeout)
+		}
+	}
+	r.mu.Unlock()
+
+	for taskID, reason := range toKill {
+		r.kill(taskID, reason)
+	}
+}
+
+func (r *Reaper) kill(taskID string, reason error) {
+	r.mu.Lock()
+	t, ok := r.tasks[taskID]
+	if ok {
+		delete(r.tasks, taskID)
+	}
+	onKill := r.onKill
+	r.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if t.cmd.Process != nil {
+		_ = t.cmd.Process.Kill()
+	}
+
+	if onKill != nil {
+		onKill(taskID, reason)
+	}
+}
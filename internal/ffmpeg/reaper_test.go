@@ -0,0 +1,60 @@
+package ffmpeg
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestReaperObserveProgressParsesLine(t *testing.T) {
+	r := NewReaper(time.Hour, time.Hour, time.Hour, nil)
+	r.Track("task-1", &exec.Cmd{})
+
+	r.ObserveProgress("task-1", "frame=  120 fps= 30 q=28.0 size=1024kB time=00:00:04.00 bitrate=2048.0kbits/s speed=1.01x")
+
+	metrics := r.Metrics()
+
+	progress, ok := metrics["task-1"]
+	if !ok {
+		t.Fatal("expected task-1 to be tracked")
+	}
+
+	if progress.Frame != 120 {
+		t.Errorf("Frame = %d, want 120", progress.Frame)
+	}
+
+	if progress.Time != "00:00:04.00" {
+		t.Errorf("Time = %q, want %q", progress.Time, "00:00:04.00")
+	}
+
+	if progress.Speed != 1.01 {
+		t.Errorf("Speed = %v, want 1.01", progress.Speed)
+	}
+}
+
+func TestReaperKillsIdleTask(t *testing.T) {
+	var killedTaskID string
+
+	r := NewReaper(time.Hour, time.Millisecond, time.Millisecond, func(taskID string, reason error) {
+		killedTaskID = taskID
+	})
+
+	cmd := exec.Command("sleep", "5")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start test process: %v", err)
+	}
+
+	r.Track("task-1", cmd)
+	time.Sleep(2 * time.Millisecond)
+	r.reapOnce()
+
+	if killedTaskID != "task-1" {
+		t.Errorf("killed task = %q, want %q", killedTaskID, "task-1")
+	}
+
+	if _, stillTracked := r.Metrics()["task-1"]; stillTracked {
+		t.Error("expected task-1 to be untracked after being killed")
+	}
+
+	_ = cmd.Wait()
+}
@@ -8,22 +8,108 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/opoccomaxao/ffmpegbox/internal/config"
+	"github.com/opoccomaxao/ffmpegbox/internal/ffmpeg/pool"
 	"github.com/opoccomaxao/ffmpegbox/internal/models"
 	"github.com/pkg/errors"
 )
 
 type Service struct {
-	cfg *config.FFmpegConfig
+	cfg    *config.FFmpegConfig
+	pool   *pool.Pool
+	reaper *Reaper
+
+	capsMu sync.RWMutex
+	caps   Capabilities
 }
 
-func NewService(cfg *config.FFmpegConfig) *Service {
+// ErrQueueFull is returned by Submit when the submission queue is at
+// capacity; callers such as HTTP handlers should respond 503 rather than
+// block indefinitely.
+var ErrQueueFull = pool.ErrQueueFull
+
+// reapInterval is how often the reaper checks running tasks for timeout or
+// idleness.
+const reapInterval = 5 * time.Second
+
+func NewService(cfg *config.FFmpegConfig, processing *config.ProcessingConfig) *Service {
 	return &Service{
-		cfg: cfg,
+		cfg:  cfg,
+		pool: pool.New(processing.WorkerCount, processing.GlobalMaxParallelTasks),
+		reaper: NewReaper(
+			processing.GetTaskTimeout(),
+			cfg.GetIdleTimeout(),
+			reapInterval,
+			nil,
+		),
+	}
+}
+
+// Reaper returns the Service's idle-process reaper so the caller can set an
+// onKill handler via SetOnKill (e.g. to mark a task StatusFailed) and start
+// it running.
+func (s *Service) Reaper() *Reaper {
+	return s.reaper
+}
+
+// SetClientLimits caps each client's concurrently running jobs at its
+// configured MaxParallelTasks, so a single client cannot occupy every
+// worker up to the pool's global max. A client with MaxParallelTasks <= 0
+// is left bounded only by that global max.
+func (s *Service) SetClientLimits(clients []config.ClientConfig) {
+	for _, client := range clients {
+		s.pool.SetClientLimit(client.Name, client.MaxParallelTasks)
 	}
 }
 
+// Submit enqueues task for processing by the worker pool and returns a
+// channel of progress results. It returns ErrQueueFull if the pool's
+// submission queue is already at capacity. Jobs from different clients are
+// drawn from the queue in round-robin order so one client cannot starve
+// another's queued work. The reaper tracks the spawned process for the
+// duration of the job and kills it if it hangs. Two-pass tasks are run as
+// two sequential pool jobs instead of the single command other tasks use;
+// see submitTwoPass.
+func (s *Service) Submit(
+	ctx context.Context,
+	clientName, inputPath, outputPath string,
+	task *models.Task,
+) (<-chan pool.Result, error) {
+	if task.RateControl == "two-pass" {
+		return s.submitTwoPass(ctx, clientName, inputPath, outputPath, task)
+	}
+
+	return s.pool.Submit(ctx, clientName, func(ctx context.Context) *exec.Cmd {
+		return s.BuildCommand(ctx, inputPath, outputPath, task)
+	}, s.jobHooks(task))
+}
+
+// jobHooks wires the reaper's liveness tracking into a pool job for task,
+// so a job started via Submit (directly or as part of a two-pass sequence)
+// is tracked for the duration of its process and untracked once it exits.
+func (s *Service) jobHooks(task *models.Task) pool.Hooks {
+	return pool.Hooks{
+		OnStart: func(cmd *exec.Cmd) {
+			s.reaper.Track(task.ID, cmd)
+		},
+		OnLine: func(line string) {
+			s.reaper.ObserveProgress(task.ID, line)
+		},
+		OnDone: func() {
+			s.reaper.Untrack(task.ID)
+		},
+	}
+}
+
+// Shutdown stops accepting new work and waits for in-flight ffmpeg
+// processes to finish, killing any still running once deadline elapses.
+func (s *Service) Shutdown(deadline time.Duration) {
+	s.pool.Shutdown(deadline)
+}
+
 //nolint:cyclop,funlen // Validation logic is inherently complex but straightforward
 func (s *Service) ValidateTask(task *models.Task) error {
 	if !s.isAllowedOutputFormat(task.OutputFormat) {
@@ -92,32 +178,167 @@ func (s *Service) ValidateTask(task *models.Task) error {
 		}
 	}
 
+	if err := s.validateOutputMode(task); err != nil {
+		return err
+	}
+
+	if err := s.validateHWAccel(task); err != nil {
+		return err
+	}
+
+	if err := s.validateThumbnails(task); err != nil {
+		return err
+	}
+
+	if err := s.validateRateControl(task); err != nil {
+		return err
+	}
+
+	if err := s.validateSubtitleTracks(task); err != nil {
+		return err
+	}
+
+	if err := s.validateAudioTracks(task); err != nil {
+		return err
+	}
+
+	if err := s.validateStorageSchemes(task); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) validateOutputMode(task *models.Task) error {
+	mode := task.OutputMode
+	if mode == "" {
+		mode = models.OutputModeFile
+	}
+
+	switch mode {
+	case models.OutputModeFile, models.OutputModeHLS, models.OutputModeDASH:
+	default:
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"output mode %q not allowed. Allowed: file, hls, dash",
+			task.OutputMode,
+		)
+	}
+
+	if !s.isAllowedOutputMode(mode) {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"output mode %q not allowed. Allowed: %v",
+			mode,
+			s.cfg.Streaming.AllowedOutputModes,
+		)
+	}
+
+	if mode == models.OutputModeFile {
+		return nil
+	}
+
+	if bounds, ok := s.cfg.Streaming.SegmentDurationBounds[string(mode)]; ok {
+		segmentDuration := task.SegmentDuration
+		if segmentDuration <= 0 {
+			segmentDuration = s.cfg.Streaming.SegmentDuration
+		}
+
+		if segmentDuration < bounds.Min || segmentDuration > bounds.Max {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"segment duration %d outside allowed range [%d,%d] for output mode %q",
+				segmentDuration,
+				bounds.Min,
+				bounds.Max,
+				mode,
+			)
+		}
+	}
+
+	if len(task.RenditionLadder) > s.cfg.Streaming.MaxRenditions {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"rendition ladder has %d rungs, exceeds maximum %d",
+			len(task.RenditionLadder),
+			s.cfg.Streaming.MaxRenditions,
+		)
+	}
+
+	for i, rung := range task.RenditionLadder {
+		if err := s.validateResolution(rung.Width, rung.Height); err != nil {
+			return errors.Wrapf(err, "rendition ladder rung[%d]", i)
+		}
+	}
+
+	if len(task.RenditionLadder) > 0 && (len(task.AudioTracks) > 0 || len(task.SubtitleTracks) > 0) {
+		return errors.Wrap(
+			models.ErrInvalidParameter,
+			"extra audio/subtitle tracks are not supported together with a rendition ladder",
+		)
+	}
+
+	if task.Framerate > 0 {
+		if err := s.validateFramerate(task.Framerate); err != nil {
+			return errors.Wrap(err, "rendition ladder")
+		}
+	}
+
 	return nil
 }
 
 // BuildCommand constructs the ffmpeg command from validated task parameters.
 // This method MUST only be called after ValidateTask has been called successfully.
 func (s *Service) BuildCommand(ctx context.Context, inputPath, outputPath string, task *models.Task) *exec.Cmd {
-	args := buildCommandArgs(inputPath, outputPath, task)
+	videoCodec := s.ResolveVideoCodec(task)
+	args := buildCommandArgs(inputPath, outputPath, task, videoCodec, s.cfg.Streaming, s.cfg.HardwareAccel)
 
 	// #nosec G204 -- All arguments are validated against whitelists before reaching this point
 	return exec.CommandContext(ctx, s.cfg.BinaryPath, args...)
 }
 
-func buildCommandArgs(inputPath, outputPath string, task *models.Task) []string {
-	args := []string{
-		"-i", inputPath,
+func buildCommandArgs(
+	inputPath, outputPath string,
+	task *models.Task,
+	videoCodec string,
+	streamingCfg config.StreamingConfig,
+	hwCfg config.HardwareAccelConfig,
+) []string {
+	var args []string
+
+	hwBackend, usesHW := hwBackendFor(videoCodec)
+	usesHW = usesHW && hwCfg.Enabled
+
+	if usesHW {
+		args = append(args, hwaccelArgs(hwBackend, hwCfg.Device)...)
 	}
 
-	if task.VideoCodec != "" {
-		args = append(args, "-c:v", task.VideoCodec)
+	args = append(args, "-i", inputPath)
+
+	if usesHW {
+		if filter := hwUploadFilter(hwBackend); filter != "" {
+			args = append(args, "-vf", filter)
+		}
+	}
+
+	if videoCodec != "" {
+		args = append(args, "-c:v", videoCodec)
 	}
 
 	if task.AudioCodec != "" {
 		args = append(args, "-c:a", task.AudioCodec)
 	}
 
-	if task.VideoBitrate != 0 {
+	switch {
+	case task.RateControl == "crf":
+		args = append(args, "-crf", strconv.Itoa(task.CRF))
+	case task.RateControl == "cq":
+		args = append(args, "-cq", strconv.Itoa(task.CRF))
+	case task.RateControl == "two-pass":
+		// Two-pass encodes need a pair of distinct commands (see
+		// BuildTwoPassCommands); callers must route them through Submit or
+		// RunCommand rather than this single-command builder.
+	case task.VideoBitrate != 0:
 		args = append(args, "-b:v", strconv.FormatInt(task.VideoBitrate, 10))
 	}
 
@@ -137,13 +358,114 @@ func buildCommandArgs(inputPath, outputPath string, task *models.Task) []string
 		args = append(args, "-preset", task.Preset)
 	}
 
-	args = append(args, "-f", task.OutputFormat)
-	args = append(args, "-y")
-	args = append(args, outputPath)
+	if len(task.RenditionLadder) == 0 {
+		args = appendTrackArgs(args, task)
+	}
+
+	switch task.OutputMode {
+	case models.OutputModeHLS:
+		return appendHLSArgs(args, outputPath, task, streamingCfg)
+	case models.OutputModeDASH:
+		return appendDASHArgs(args, outputPath, task, streamingCfg)
+	default:
+		args = append(args, "-f", task.OutputFormat)
+		args = append(args, "-y")
+		args = append(args, outputPath)
+
+		return args
+	}
+}
+
+// appendHLSArgs emits the ffmpeg arguments that package the output as an HLS
+// playlist (optionally with a per-rendition variant stream map) inside the
+// outputPath directory.
+func appendHLSArgs(args []string, outputPath string, task *models.Task, streamingCfg config.StreamingConfig) []string {
+	segmentDuration := task.SegmentDuration
+	if segmentDuration <= 0 {
+		segmentDuration = streamingCfg.SegmentDuration
+	}
+
+	playlistType := task.PlaylistType
+	if playlistType == "" {
+		playlistType = streamingCfg.PlaylistType
+	}
+
+	if len(task.RenditionLadder) > 0 {
+		args = appendLadderArgs(args, task.RenditionLadder)
+	}
+
+	args = append(args,
+		"-hls_time", strconv.Itoa(segmentDuration),
+		"-hls_playlist_type", playlistType,
+		"-hls_segment_filename", filepath.Join(outputPath, "segment_%v_%03d.ts"),
+	)
+
+	if task.EncryptionKeyURI != "" {
+		args = append(args, "-hls_key_info_file", task.EncryptionKeyURI)
+	}
+
+	if len(task.RenditionLadder) > 0 {
+		args = append(args, "-var_stream_map", varStreamMap(len(task.RenditionLadder)))
+		args = append(args, "-master_pl_name", "master.m3u8")
+		args = append(args, "-y", filepath.Join(outputPath, "variant_%v.m3u8"))
+	} else {
+		args = append(args, "-y", filepath.Join(outputPath, "master.m3u8"))
+	}
+
+	return args
+}
+
+// appendDASHArgs emits the ffmpeg arguments that package the output as an
+// MPEG-DASH manifest inside the outputPath directory.
+func appendDASHArgs(args []string, outputPath string, task *models.Task, streamingCfg config.StreamingConfig) []string {
+	segmentDuration := task.SegmentDuration
+	if segmentDuration <= 0 {
+		segmentDuration = streamingCfg.SegmentDuration
+	}
+
+	if len(task.RenditionLadder) > 0 {
+		args = appendLadderArgs(args, task.RenditionLadder)
+		args = append(args, "-adaptation_sets", "id=0,streams=v id=1,streams=a")
+	}
+
+	args = append(args,
+		"-f", "dash",
+		"-seg_duration", strconv.Itoa(segmentDuration),
+		"-y", filepath.Join(outputPath, "manifest.mpd"),
+	)
 
 	return args
 }
 
+// appendLadderArgs appends one -map/-s/-b:v group per rendition ladder rung
+// so a single ffmpeg invocation can emit all variants.
+func appendLadderArgs(args []string, ladder []models.BitrateRung) []string {
+	for i, rung := range ladder {
+		args = append(args,
+			"-map", "0:v", "-map", "0:a",
+			"-s:v:"+strconv.Itoa(i), fmt.Sprintf("%dx%d", rung.Width, rung.Height),
+			"-b:v:"+strconv.Itoa(i), strconv.FormatInt(rung.VideoBitrate, 10),
+		)
+
+		if rung.AudioBitrate != 0 {
+			args = append(args, "-b:a:"+strconv.Itoa(i), strconv.FormatInt(rung.AudioBitrate, 10))
+		}
+	}
+
+	return args
+}
+
+// varStreamMap builds the "v:0,a:0 v:1,a:1 ..." argument to -var_stream_map
+// for an n-rung rendition ladder.
+func varStreamMap(rungs int) string {
+	groups := make([]string, rungs)
+	for i := range groups {
+		groups[i] = fmt.Sprintf("v:%d,a:%d", i, i)
+	}
+
+	return strings.Join(groups, " ")
+}
+
 func (s *Service) GetVersion(ctx context.Context) (string, error) {
 	// #nosec G204 -- Binary path is from config, -version is a safe static argument
 	cmd := exec.CommandContext(ctx, s.cfg.BinaryPath, "-version")
@@ -161,21 +483,107 @@ func (s *Service) GetVersion(ctx context.Context) (string, error) {
 	return string(output), nil
 }
 
-func (s *Service) GenerateOutputFilename(taskID, inputFilename string, task *models.Task) string {
+func (s *Service) GenerateOutputKey(taskID, inputFilename string, task *models.Task) string {
 	baseName := strings.TrimSuffix(inputFilename, filepath.Ext(inputFilename))
 	if baseName == "" {
 		baseName = taskID
 	}
 
-	extension := task.OutputFormat
+	switch task.OutputMode {
+	case models.OutputModeHLS, models.OutputModeDASH:
+		return baseName + "-processed"
+	default:
+		return fmt.Sprintf("%s-processed.%s", baseName, task.OutputFormat)
+	}
+}
+
+// GenerateOutputFilename derives the output filename for task from
+// inputURI, which may be a bare filename or a full scheme-qualified URI
+// (e.g. "s3://bucket/key/in.mp4"); only the path component after the last
+// "://" and "/" is used.
+func (s *Service) GenerateOutputFilename(taskID, inputURI string, task *models.Task) string {
+	_, rest, ok := strings.Cut(inputURI, "://")
+	if !ok {
+		rest = inputURI
+	}
 
-	return fmt.Sprintf("%s-processed.%s", baseName, extension)
+	return s.GenerateOutputKey(taskID, filepath.Base(rest), task)
 }
 
 func (s *Service) isAllowedOutputFormat(format string) bool {
 	return slices.Contains(s.cfg.AllowedOutputFormats, format)
 }
 
+// isAllowedInputScheme reports whether scheme is permitted for task input
+// URIs. An empty AllowedInputSchemes list means the restriction isn't
+// configured, so every scheme is allowed.
+func (s *Service) isAllowedInputScheme(scheme string) bool {
+	if len(s.cfg.AllowedInputSchemes) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.cfg.AllowedInputSchemes, scheme)
+}
+
+// isAllowedOutputScheme reports whether scheme is permitted for task output
+// URIs. An empty AllowedOutputSchemes list means the restriction isn't
+// configured, so every scheme is allowed.
+func (s *Service) isAllowedOutputScheme(scheme string) bool {
+	if len(s.cfg.AllowedOutputSchemes) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.cfg.AllowedOutputSchemes, scheme)
+}
+
+func uriScheme(uri string) string {
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok {
+		return ""
+	}
+
+	return scheme
+}
+
+// validateStorageSchemes checks task.InputURI and task.OutputURI, when set,
+// against the configured scheme allow-lists.
+func (s *Service) validateStorageSchemes(task *models.Task) error {
+	if task.InputURI != "" {
+		if scheme := uriScheme(task.InputURI); !s.isAllowedInputScheme(scheme) {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"input scheme %q not allowed. Allowed: %v",
+				scheme,
+				s.cfg.AllowedInputSchemes,
+			)
+		}
+	}
+
+	if task.OutputURI != "" {
+		if scheme := uriScheme(task.OutputURI); !s.isAllowedOutputScheme(scheme) {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"output scheme %q not allowed. Allowed: %v",
+				scheme,
+				s.cfg.AllowedOutputSchemes,
+			)
+		}
+	}
+
+	return nil
+}
+
+// isAllowedOutputMode reports whether mode is permitted. An empty
+// AllowedOutputModes list means the mode restriction isn't configured, so
+// every mode is allowed.
+func (s *Service) isAllowedOutputMode(mode models.OutputMode) bool {
+	if len(s.cfg.Streaming.AllowedOutputModes) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.cfg.Streaming.AllowedOutputModes, string(mode))
+}
+
 func (s *Service) isAllowedVideoCodec(codec string) bool {
 	return slices.Contains(s.cfg.AllowedVideoCodecs, codec)
 }
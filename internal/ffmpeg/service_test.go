@@ -21,7 +21,7 @@ func TestServiceValidateTask(t *testing.T) {
 		MaxFramerate:         120,
 	}
 
-	svc := NewService(cfg)
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 2, GlobalMaxParallelTasks: 10})
 
 	tests := []struct {
 		name        string
@@ -250,7 +250,7 @@ func TestBuildCommandArgs(t *testing.T) {
 
 	for _, tC := range tests {
 		t.Run(tC.name, func(t *testing.T) {
-			got := buildCommandArgs(tC.inputPath, tC.outputPath, tC.task)
+			got := buildCommandArgs(tC.inputPath, tC.outputPath, tC.task, tC.task.VideoCodec, config.StreamingConfig{}, config.HardwareAccelConfig{})
 
 			if len(got) != len(tC.want) {
 				t.Errorf("buildCommandArgs() length = %d, want %d\nGot:  %v\nWant: %v", len(got), len(tC.want), got, tC.want)
@@ -267,6 +267,136 @@ func TestBuildCommandArgs(t *testing.T) {
 	}
 }
 
+func TestBuildCommandArgsHLS(t *testing.T) {
+	task := &models.Task{
+		OutputMode:   models.OutputModeHLS,
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+	}
+
+	streamingCfg := config.StreamingConfig{
+		SegmentDuration: 6,
+		PlaylistType:    "vod",
+	}
+
+	got := buildCommandArgs("/tmp/input.mp4", "/tmp/out", task, task.VideoCodec, streamingCfg, config.HardwareAccelConfig{})
+
+	want := []string{
+		"-i", "/tmp/input.mp4",
+		"-c:v", "libx264",
+		"-hls_time", "6",
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", "/tmp/out/segment_%v_%03d.ts",
+		"-y", "/tmp/out/master.m3u8",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("buildCommandArgs() length = %d, want %d\nGot:  %v\nWant: %v", len(got), len(want), got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("buildCommandArgs()[%d] = %q, want %q\nGot:  %v\nWant: %v", i, got[i], want[i], got, want)
+		}
+	}
+}
+
+func TestBuildCommandArgsHLSLadderAndEncryption(t *testing.T) {
+	task := &models.Task{
+		OutputMode:       models.OutputModeHLS,
+		OutputFormat:     "mp4",
+		EncryptionKeyURI: "/tmp/enc.keyinfo",
+		RenditionLadder: []models.BitrateRung{
+			{Width: 1280, Height: 720, VideoBitrate: 2_500_000, AudioBitrate: 128_000},
+		},
+	}
+
+	streamingCfg := config.StreamingConfig{SegmentDuration: 6, PlaylistType: "vod"}
+
+	got := buildCommandArgs("/tmp/input.mp4", "/tmp/out", task, task.VideoCodec, streamingCfg, config.HardwareAccelConfig{})
+
+	joined := strings.Join(got, " ")
+	if !strings.Contains(joined, "-b:a:0 128000") {
+		t.Errorf("buildCommandArgs() = %v, want per-rung -b:a:0 128000", got)
+	}
+
+	if !strings.Contains(joined, "-hls_key_info_file /tmp/enc.keyinfo") {
+		t.Errorf("buildCommandArgs() = %v, want -hls_key_info_file /tmp/enc.keyinfo", got)
+	}
+}
+
+func TestValidateOutputModeRejectsDisallowedMode(t *testing.T) {
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"libx264"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"fast"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+		Streaming: config.StreamingConfig{
+			SegmentDuration:    6,
+			PlaylistType:       "vod",
+			MaxRenditions:      3,
+			AllowedOutputModes: []string{"file", "hls"},
+			SegmentDurationBounds: map[string]config.SegmentDurationRange{
+				"hls": {Min: 2, Max: 10},
+			},
+		},
+	}
+
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 2, GlobalMaxParallelTasks: 10})
+
+	err := svc.ValidateTask(&models.Task{OutputFormat: "mp4", OutputMode: models.OutputModeDASH})
+	if err == nil {
+		t.Fatal("expected error for output mode not in AllowedOutputModes")
+	}
+
+	err = svc.ValidateTask(&models.Task{OutputFormat: "mp4", OutputMode: models.OutputModeHLS, SegmentDuration: 60})
+	if err == nil {
+		t.Fatal("expected error for segment duration outside configured bounds")
+	}
+
+	err = svc.ValidateTask(&models.Task{OutputFormat: "mp4", OutputMode: models.OutputModeHLS, SegmentDuration: 4})
+	if err != nil {
+		t.Fatalf("expected in-bounds segment duration to validate, got %v", err)
+	}
+}
+
+func TestValidateOutputModeRejectsExtraTracksWithRenditionLadder(t *testing.T) {
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"libx264"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"fast"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+		Streaming: config.StreamingConfig{
+			SegmentDuration:    6,
+			PlaylistType:       "vod",
+			MaxRenditions:      3,
+			AllowedOutputModes: []string{"file", "hls"},
+		},
+	}
+
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 2, GlobalMaxParallelTasks: 10})
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat: "mp4",
+		OutputMode:   models.OutputModeHLS,
+		RenditionLadder: []models.BitrateRung{
+			{Width: 1280, Height: 720, VideoBitrate: 2_500_000},
+		},
+		AudioTracks: []models.AudioTrack{{Index: 1, Codec: "aac"}},
+	})
+	if err == nil {
+		t.Fatal("expected error for a rendition ladder combined with extra audio tracks, since the ladder owns the output -map layout")
+	}
+}
+
 func TestServiceBuildCommand(t *testing.T) {
 	cfg := &config.FFmpegConfig{
 		BinaryPath:           "/usr/bin/ffmpeg",
@@ -279,7 +409,7 @@ func TestServiceBuildCommand(t *testing.T) {
 		MaxFramerate:         120,
 	}
 
-	svc := NewService(cfg)
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 2, GlobalMaxParallelTasks: 10})
 
 	task := &models.Task{
 		OutputFormat: "mp4",
@@ -298,7 +428,7 @@ func TestServiceBuildCommand(t *testing.T) {
 	}
 }
 
-func TestGenerateOutputFilename(t *testing.T) {
+func TestGenerateOutputKey(t *testing.T) {
 	cfg := &config.FFmpegConfig{
 		BinaryPath:           "/usr/bin/ffmpeg",
 		AllowedOutputFormats: []string{"mp4", "webm", "mp3"},
@@ -310,7 +440,7 @@ func TestGenerateOutputFilename(t *testing.T) {
 		MaxFramerate:         120,
 	}
 
-	svc := NewService(cfg)
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 2, GlobalMaxParallelTasks: 10})
 
 	tests := []struct {
 		name          string
@@ -350,7 +480,54 @@ func TestGenerateOutputFilename(t *testing.T) {
 
 	for _, tC := range tests {
 		t.Run(tC.name, func(t *testing.T) {
-			got := svc.GenerateOutputFilename(tC.taskID, tC.inputFilename, tC.task)
+			got := svc.GenerateOutputKey(tC.taskID, tC.inputFilename, tC.task)
+
+			if got != tC.wantFilename {
+				t.Errorf("GenerateOutputKey() = %q, want %q", got, tC.wantFilename)
+			}
+		})
+	}
+}
+
+func TestGenerateOutputFilenameIsSchemeAware(t *testing.T) {
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"libx264"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"medium"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+	}
+
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+
+	tests := []struct {
+		name         string
+		inputURI     string
+		wantFilename string
+	}{
+		{
+			name:         "bare filename",
+			inputURI:     "video.avi",
+			wantFilename: "video-processed.mp4",
+		},
+		{
+			name:         "s3 uri",
+			inputURI:     "s3://bucket/tasks/1/video.avi",
+			wantFilename: "video-processed.mp4",
+		},
+		{
+			name:         "https uri",
+			inputURI:     "https://example.com/videos/video.avi",
+			wantFilename: "video-processed.mp4",
+		},
+	}
+
+	for _, tC := range tests {
+		t.Run(tC.name, func(t *testing.T) {
+			got := svc.GenerateOutputFilename("test-task-id", tC.inputURI, &models.Task{OutputFormat: "mp4"})
 
 			if got != tC.wantFilename {
 				t.Errorf("GenerateOutputFilename() = %q, want %q", got, tC.wantFilename)
@@ -358,3 +535,51 @@ func TestGenerateOutputFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateStorageSchemesRejectsDisallowedScheme(t *testing.T) {
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"libx264"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"medium"},
+		AllowedInputSchemes:  []string{"s3", "file"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+	}
+
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat: "mp4",
+		InputURI:     "ftp://host/video.avi",
+	})
+	if err == nil {
+		t.Fatal("expected error for disallowed input scheme")
+	}
+}
+
+func TestValidateStorageSchemesAllowsAnySchemeWhenUnconfigured(t *testing.T) {
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"libx264"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"medium"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+	}
+
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat: "mp4",
+		InputURI:     "ftp://host/video.avi",
+		OutputURI:    "s3://bucket/out.mp4",
+	})
+	if err != nil {
+		t.Errorf("ValidateTask() error = %v, want nil when no scheme allow-list is configured", err)
+	}
+}
@@ -0,0 +1,72 @@
+package ffmpeg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"github.com/opoccomaxao/ffmpegbox/internal/storage"
+	"github.com/pkg/errors"
+)
+
+// ProcessWithStorage fetches inputKey from backend into a temp directory,
+// runs the task's ffmpeg command against it, and uploads the result back to
+// backend under the task's generated output key. It must only be called
+// after ValidateTask has succeeded.
+func (s *Service) ProcessWithStorage(ctx context.Context, backend storage.Backend, inputKey string, task *models.Task) error {
+	tmpDir, err := os.MkdirTemp("", "ffmpegbox-"+task.ID+"-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	localInput := filepath.Join(tmpDir, filepath.Base(inputKey))
+	if err := backend.Get(ctx, inputKey, localInput); err != nil {
+		return errors.Wrap(err, "failed to fetch input")
+	}
+
+	outputKey := s.GenerateOutputKey(task.ID, inputKey, task)
+	localOutput := filepath.Join(tmpDir, filepath.Base(outputKey))
+
+	if err := s.RunCommand(ctx, localInput, localOutput, task); err != nil {
+		return err
+	}
+
+	if err := backend.Put(ctx, outputKey, localOutput); err != nil {
+		return errors.Wrap(err, "failed to upload output")
+	}
+
+	return nil
+}
+
+// ProcessTask fetches task.InputURI through registry to a local path, runs
+// the task's ffmpeg command against it with the output written into a temp
+// directory, and publishes the result to task.OutputURI. It must only be
+// called after ValidateTask has succeeded.
+func (s *Service) ProcessTask(ctx context.Context, registry *storage.SchemeRegistry, task *models.Task) error {
+	localInput, cleanup, err := registry.Fetch(ctx, task.InputURI)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch input")
+	}
+	defer cleanup()
+
+	tmpDir, err := os.MkdirTemp("", "ffmpegbox-"+task.ID+"-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	outputFilename := s.GenerateOutputFilename(task.ID, task.OutputURI, task)
+	localOutput := filepath.Join(tmpDir, outputFilename)
+
+	if err := s.RunCommand(ctx, localInput, localOutput, task); err != nil {
+		return err
+	}
+
+	if err := registry.Publish(ctx, localOutput, task.OutputURI); err != nil {
+		return errors.Wrap(err, "failed to publish output")
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"github.com/pkg/errors"
+)
+
+// BuildThumbnailCommand constructs the second ffmpeg invocation that
+// extracts a sprite sheet of still thumbnails from inputPath, per
+// task.Thumbnails. It must only be called after ValidateTask has succeeded.
+func (s *Service) BuildThumbnailCommand(ctx context.Context, inputPath, spritePath string, task *models.Task) (*exec.Cmd, error) {
+	if task.Thumbnails == nil {
+		return nil, errors.New("task has no thumbnail spec")
+	}
+
+	cols, rows := spriteGrid(task.Thumbnails)
+
+	vf := fmt.Sprintf(
+		"fps=1/%d,scale=%dx%d,tile=%dx%d",
+		task.Thumbnails.Interval, task.Thumbnails.Width, task.Thumbnails.Height, cols, rows,
+	)
+
+	args := []string{"-i", inputPath, "-vf", vf, "-y", spritePath}
+
+	// #nosec G204 -- All arguments are validated against whitelists before reaching this point
+	return exec.CommandContext(ctx, s.cfg.BinaryPath, args...), nil
+}
+
+// GenerateSpriteVTT returns the WebVTT content mapping each thumbnail tile in
+// the generated sprite sheet to its source time range, for use by scrubbing
+// UIs. spriteFilename is the name of the sprite image as the VTT should
+// reference it (typically relative to the VTT file itself).
+func (s *Service) GenerateSpriteVTT(task *models.Task, spriteFilename string) (string, error) {
+	if task.Thumbnails == nil {
+		return "", errors.New("task has no thumbnail spec")
+	}
+
+	spec := task.Thumbnails
+	cols, _ := spriteGrid(spec)
+
+	var b strings.Builder
+
+	b.WriteString("WEBVTT\n\n")
+
+	for i := range spec.Count {
+		start := time.Duration(i*spec.Interval) * time.Second
+		end := start + time.Duration(spec.Interval)*time.Second
+		col := i % cols
+		row := i / cols
+		x := col * spec.Width
+		y := row * spec.Height
+
+		fmt.Fprintf(&b, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+			formatVTTTime(start), formatVTTTime(end), spriteFilename, x, y, spec.Width, spec.Height)
+	}
+
+	return b.String(), nil
+}
+
+// spriteGrid returns the tile grid dimensions (columns, rows) for a
+// ThumbnailSpec, deriving rows from Count and SpriteColumns.
+func spriteGrid(spec *models.ThumbnailSpec) (cols, rows int) {
+	cols = spec.SpriteColumns
+	if cols < 1 {
+		cols = 1
+	}
+
+	rows = int(math.Ceil(float64(spec.Count) / float64(cols)))
+	if rows < 1 {
+		rows = 1
+	}
+
+	return cols, rows
+}
+
+func formatVTTTime(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	sec := int(d.Seconds()) % 60
+	ms := int(d.Milliseconds()) % 1000
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, sec, ms)
+}
+
+func (s *Service) validateThumbnails(task *models.Task) error {
+	if task.Thumbnails == nil {
+		return nil
+	}
+
+	spec := task.Thumbnails
+
+	if spec.Interval < 1 {
+		return errors.Wrap(models.ErrInvalidParameter, "thumbnails: interval must be >= 1 second")
+	}
+
+	if spec.Count < 1 {
+		return errors.Wrap(models.ErrInvalidParameter, "thumbnails: count must be >= 1")
+	}
+
+	if err := s.validateResolution(spec.Width, spec.Height); err != nil {
+		return errors.Wrap(err, "thumbnails")
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package ffmpeg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/config"
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+)
+
+func TestBuildThumbnailCommand(t *testing.T) {
+	cfg := &config.FFmpegConfig{BinaryPath: "/usr/bin/ffmpeg"}
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+
+	task := &models.Task{
+		Thumbnails: &models.ThumbnailSpec{
+			Interval:      10,
+			Width:         160,
+			Height:        90,
+			Count:         4,
+			SpriteColumns: 2,
+		},
+	}
+
+	cmd, err := svc.BuildThumbnailCommand(context.Background(), "/tmp/input.mp4", "/tmp/sprite.jpg", task)
+	if err != nil {
+		t.Fatalf("BuildThumbnailCommand() error = %v", err)
+	}
+
+	want := "-vf fps=1/10,scale=160x90,tile=2x2"
+	if got := strings.Join(cmd.Args, " "); !strings.Contains(got, want) {
+		t.Errorf("BuildThumbnailCommand() args = %q, want to contain %q", got, want)
+	}
+}
+
+func TestGenerateSpriteVTT(t *testing.T) {
+	cfg := &config.FFmpegConfig{BinaryPath: "/usr/bin/ffmpeg"}
+	svc := NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+
+	task := &models.Task{
+		Thumbnails: &models.ThumbnailSpec{
+			Interval:      10,
+			Width:         160,
+			Height:        90,
+			Count:         2,
+			SpriteColumns: 2,
+		},
+	}
+
+	vtt, err := svc.GenerateSpriteVTT(task, "sprite.jpg")
+	if err != nil {
+		t.Fatalf("GenerateSpriteVTT() error = %v", err)
+	}
+
+	if !strings.HasPrefix(vtt, "WEBVTT\n\n") {
+		t.Errorf("GenerateSpriteVTT() missing WEBVTT header: %q", vtt)
+	}
+
+	if !strings.Contains(vtt, "00:00:00.000 --> 00:00:10.000\nsprite.jpg#xywh=0,0,160,90") {
+		t.Errorf("GenerateSpriteVTT() missing first cue: %q", vtt)
+	}
+
+	if !strings.Contains(vtt, "00:00:10.000 --> 00:00:20.000\nsprite.jpg#xywh=160,0,160,90") {
+		t.Errorf("GenerateSpriteVTT() missing second cue: %q", vtt)
+	}
+}
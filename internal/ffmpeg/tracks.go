@@ -0,0 +1,149 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"github.com/pkg/errors"
+)
+
+// isAllowedSubtitleCodec reports whether codec is permitted. An empty
+// AllowedSubtitleCodecs list means the restriction isn't configured, so
+// every codec is allowed.
+func (s *Service) isAllowedSubtitleCodec(codec string) bool {
+	if len(s.cfg.AllowedSubtitleCodecs) == 0 {
+		return true
+	}
+
+	return slices.Contains(s.cfg.AllowedSubtitleCodecs, codec)
+}
+
+func (s *Service) validateSubtitleTracks(task *models.Task) error {
+	burnCount := 0
+
+	for i, track := range task.SubtitleTracks {
+		if !s.isAllowedSubtitleCodec(track.Codec) {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"subtitle_tracks[%d]: codec %q not allowed. Allowed: %v",
+				i,
+				track.Codec,
+				s.cfg.AllowedSubtitleCodecs,
+			)
+		}
+
+		if track.Codec == "mov_text" && task.OutputFormat != "mp4" && task.OutputFormat != "mov" {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"subtitle_tracks[%d]: mov_text subtitle codec requires output_format mp4 or mov, got %q",
+				i,
+				task.OutputFormat,
+			)
+		}
+
+		if track.Burn {
+			burnCount++
+
+			if task.VideoCodec == "copy" {
+				return errors.Wrap(models.ErrInvalidParameter, "subtitle burn-in is incompatible with video codec \"copy\"")
+			}
+		}
+	}
+
+	if burnCount > 1 {
+		return errors.Wrap(models.ErrInvalidParameter, "only one subtitle track may be burned in")
+	}
+
+	return nil
+}
+
+func (s *Service) validateAudioTracks(task *models.Task) error {
+	for i, track := range task.AudioTracks {
+		if track.Codec != "" && !s.isAllowedAudioCodec(track.Codec) {
+			return errors.Wrapf(
+				models.ErrInvalidParameter,
+				"audio_tracks[%d]: codec %q not allowed. Allowed: %v",
+				i,
+				track.Codec,
+				s.cfg.AllowedAudioCodecs,
+			)
+		}
+	}
+
+	return nil
+}
+
+// appendTrackArgs emits the -map/-c:s/-c:a/-metadata arguments for a task's
+// extra subtitle and audio tracks, plus the primary video/audio streams
+// those extra tracks require to be mapped explicitly. At most one subtitle
+// track may have Burn set; when it does, the subtitle is composited onto
+// the primary video via -filter_complex instead of being muxed as its own
+// stream, and the filter's output is mapped in place of the primary video
+// stream. A task with no extra tracks and no burn-in emits nothing here,
+// leaving ffmpeg's default stream selection in place; once any -map is
+// emitted ffmpeg disables that default, so the primary streams are only
+// mapped explicitly when extra tracks or a burn-in force it.
+func appendTrackArgs(args []string, task *models.Task) []string {
+	if len(task.AudioTracks) == 0 && len(task.SubtitleTracks) == 0 {
+		return args
+	}
+
+	burnIndex := -1
+
+	for _, track := range task.SubtitleTracks {
+		if track.Burn {
+			burnIndex = track.Index
+
+			break
+		}
+	}
+
+	if burnIndex >= 0 {
+		args = append(args, "-filter_complex", fmt.Sprintf("[0:v][0:s:%d]overlay[vout]", burnIndex))
+		args = append(args, "-map", "[vout]")
+	} else {
+		args = append(args, "-map", "0:v:0")
+	}
+
+	args = append(args, "-map", "0:a:0")
+
+	for i, track := range task.AudioTracks {
+		args = append(args, "-map", fmt.Sprintf("0:a:%d", track.Index))
+
+		if track.Codec != "" {
+			args = append(args, "-c:a:"+strconv.Itoa(i+1), track.Codec)
+		}
+
+		if track.Bitrate != 0 {
+			args = append(args, "-b:a:"+strconv.Itoa(i+1), strconv.FormatInt(track.Bitrate, 10))
+		}
+
+		if track.Language != "" {
+			args = append(args, "-metadata:s:a:"+strconv.Itoa(i+1), "language="+track.Language)
+		}
+	}
+
+	outIndex := 0
+
+	for _, track := range task.SubtitleTracks {
+		if track.Burn {
+			continue
+		}
+
+		args = append(args, "-map", fmt.Sprintf("0:s:%d", track.Index))
+
+		if track.Codec != "" {
+			args = append(args, "-c:s:"+strconv.Itoa(outIndex), track.Codec)
+		}
+
+		if track.Language != "" {
+			args = append(args, "-metadata:s:s:"+strconv.Itoa(outIndex), "language="+track.Language)
+		}
+
+		outIndex++
+	}
+
+	return args
+}
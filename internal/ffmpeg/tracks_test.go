@@ -0,0 +1,175 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/config"
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+)
+
+func newTracksService(t *testing.T) *Service {
+	t.Helper()
+
+	cfg := &config.FFmpegConfig{
+		BinaryPath:            "/usr/bin/ffmpeg",
+		AllowedOutputFormats:  []string{"mp4", "mkv"},
+		AllowedVideoCodecs:    []string{"libx264", "copy"},
+		AllowedAudioCodecs:    []string{"aac", "copy"},
+		AllowedPresets:        []string{"medium"},
+		AllowedSubtitleCodecs: []string{"mov_text", "webvtt", "copy"},
+		MaxWidth:              3840,
+		MaxHeight:             2160,
+		MaxFramerate:          120,
+	}
+
+	return NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+}
+
+func TestValidateSubtitleTracksRejectsDisallowedCodec(t *testing.T) {
+	svc := newTracksService(t)
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat:   "mp4",
+		SubtitleTracks: []models.SubtitleTrack{{Index: 0, Codec: "dvd_subtitle"}},
+	})
+	if err == nil {
+		t.Fatal("expected error for disallowed subtitle codec")
+	}
+}
+
+func TestValidateSubtitleTracksRejectsMovTextOutsideMP4(t *testing.T) {
+	svc := newTracksService(t)
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat:   "mkv",
+		SubtitleTracks: []models.SubtitleTrack{{Index: 0, Codec: "mov_text"}},
+	})
+	if err == nil {
+		t.Fatal("expected error for mov_text subtitle codec with mkv output")
+	}
+}
+
+func TestValidateSubtitleTracksRejectsBurnWithCopyVideo(t *testing.T) {
+	svc := newTracksService(t)
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat:   "mp4",
+		VideoCodec:     "copy",
+		SubtitleTracks: []models.SubtitleTrack{{Index: 0, Codec: "webvtt", Burn: true}},
+	})
+	if err == nil {
+		t.Fatal("expected error for burned-in subtitle with copy video codec")
+	}
+}
+
+func TestValidateSubtitleTracksRejectsMultipleBurns(t *testing.T) {
+	svc := newTracksService(t)
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+		SubtitleTracks: []models.SubtitleTrack{
+			{Index: 0, Codec: "webvtt", Burn: true},
+			{Index: 1, Codec: "webvtt", Burn: true},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error for more than one burned-in subtitle track")
+	}
+}
+
+func TestAppendTrackArgsMapsSubtitleAndAudioTracks(t *testing.T) {
+	task := &models.Task{
+		OutputFormat: "mp4",
+		SubtitleTracks: []models.SubtitleTrack{
+			{Index: 0, Codec: "mov_text", Language: "eng"},
+		},
+		AudioTracks: []models.AudioTrack{
+			{Index: 1, Codec: "aac", Bitrate: 96000, Language: "spa"},
+		},
+	}
+
+	got := appendTrackArgs(nil, task)
+	joined := strings.Join(got, " ")
+
+	for _, want := range []string{
+		"-map 0:v:0",
+		"-map 0:a:0",
+		"-map 0:a:1",
+		"-c:a:1 aac",
+		"-b:a:1 96000",
+		"-metadata:s:a:1 language=spa",
+		"-map 0:s:0",
+		"-c:s:0 mov_text",
+		"-metadata:s:s:0 language=eng",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("appendTrackArgs() = %q, missing %q", joined, want)
+		}
+	}
+}
+
+func TestAppendTrackArgsOmitsMapsWithoutExtraTracks(t *testing.T) {
+	task := &models.Task{
+		OutputFormat: "mp3",
+		AudioCodec:   "libmp3lame",
+	}
+
+	got := appendTrackArgs(nil, task)
+	if len(got) != 0 {
+		t.Errorf("appendTrackArgs() = %v, want no args for a task with no extra tracks or burn-in so ffmpeg's default stream selection applies", got)
+	}
+}
+
+func TestAppendTrackArgsIndexesMuxedSubtitlesByOutputPosition(t *testing.T) {
+	task := &models.Task{
+		OutputFormat: "mkv",
+		SubtitleTracks: []models.SubtitleTrack{
+			{Index: 0, Codec: "webvtt", Burn: true},
+			{Index: 1, Codec: "webvtt", Language: "eng"},
+		},
+		VideoCodec: "libx264",
+	}
+
+	got := appendTrackArgs(nil, task)
+	joined := strings.Join(got, " ")
+
+	if !strings.Contains(joined, "-c:s:0 webvtt") {
+		t.Errorf("appendTrackArgs() = %q, want the only muxed subtitle at output index 0 despite a preceding burned track", joined)
+	}
+
+	if strings.Contains(joined, "-c:s:1") {
+		t.Errorf("appendTrackArgs() = %q, output subtitle index should skip the burned-in track entirely", joined)
+	}
+}
+
+func TestAppendTrackArgsBurnsSubtitleViaFilterComplex(t *testing.T) {
+	task := &models.Task{
+		OutputFormat:   "mp4",
+		SubtitleTracks: []models.SubtitleTrack{{Index: 2, Codec: "webvtt", Burn: true}},
+	}
+
+	got := appendTrackArgs(nil, task)
+	joined := strings.Join(got, " ")
+
+	if !strings.Contains(joined, "-filter_complex [0:v][0:s:2]overlay[vout]") {
+		t.Errorf("appendTrackArgs() = %q, want burn-in filter_complex", joined)
+	}
+
+	if !strings.Contains(joined, "-map [vout]") {
+		t.Errorf("appendTrackArgs() = %q, want the filter_complex output mapped in place of the primary video stream", joined)
+	}
+
+	if strings.Contains(joined, "-map 0:v:0") {
+		t.Errorf("appendTrackArgs() = %q, should not also map the raw primary video stream when burning in a subtitle", joined)
+	}
+
+	if !strings.Contains(joined, "-map 0:a:0") {
+		t.Errorf("appendTrackArgs() = %q, primary audio must still be mapped when burning in a subtitle", joined)
+	}
+
+	if strings.Contains(joined, "-map 0:s:2") {
+		t.Errorf("appendTrackArgs() = %q, burned-in subtitle should not also be mapped as a stream", joined)
+	}
+}
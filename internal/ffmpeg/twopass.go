@@ -0,0 +1,245 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/ffmpeg/pool"
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"github.com/pkg/errors"
+)
+
+func (s *Service) validateRateControl(task *models.Task) error {
+	if task.RateControl == "" {
+		return nil
+	}
+
+	if !slices.Contains(s.cfg.RateControl.AllowedRateControls, task.RateControl) {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"rate control %q not allowed. Allowed: %v",
+			task.RateControl,
+			s.cfg.RateControl.AllowedRateControls,
+		)
+	}
+
+	if task.RateControl != "crf" && task.RateControl != "cq" {
+		return nil
+	}
+
+	if task.VideoCodec == "copy" {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"rate control %q is incompatible with video codec \"copy\"",
+			task.RateControl,
+		)
+	}
+
+	crfRange, ok := s.cfg.RateControl.CRFRanges[task.VideoCodec]
+	if !ok {
+		return errors.Wrapf(models.ErrInvalidParameter, "no CRF range configured for video codec %q", task.VideoCodec)
+	}
+
+	if task.CRF < crfRange.Min || task.CRF > crfRange.Max {
+		return errors.Wrapf(
+			models.ErrInvalidParameter,
+			"crf %d out of range for codec %q: must be %d-%d",
+			task.CRF,
+			task.VideoCodec,
+			crfRange.Min,
+			crfRange.Max,
+		)
+	}
+
+	return nil
+}
+
+// passLogPrefix returns the per-task temp-file prefix ffmpeg's two-pass
+// stats file is written under.
+func passLogPrefix(task *models.Task) string {
+	return filepath.Join(os.TempDir(), "ffmpegbox-"+task.ID+"-2pass")
+}
+
+// appendTwoPassSharedArgs appends the encode settings that must stay
+// identical across both passes of a two-pass encode: video codec,
+// resolution, framerate, and preset. Letting these diverge between passes
+// invalidates the first pass's stats file and makes the two-pass output
+// differ from what buildCommandArgs would produce for a single-pass encode
+// of the same task.
+func appendTwoPassSharedArgs(args []string, task *models.Task) []string {
+	if task.VideoCodec != "" {
+		args = append(args, "-c:v", task.VideoCodec)
+	}
+
+	if task.Width > 0 && task.Height > 0 {
+		args = append(args, "-s", fmt.Sprintf("%dx%d", task.Width, task.Height))
+	}
+
+	if task.Framerate > 0 {
+		args = append(args, "-r", strconv.Itoa(task.Framerate))
+	}
+
+	if task.Preset != "" {
+		args = append(args, "-preset", task.Preset)
+	}
+
+	return args
+}
+
+func buildPass1Args(inputPath string, task *models.Task, prefix string) []string {
+	args := []string{"-i", inputPath}
+	args = appendTwoPassSharedArgs(args, task)
+
+	if task.VideoBitrate != 0 {
+		args = append(args, "-b:v", strconv.FormatInt(task.VideoBitrate, 10))
+	}
+
+	return append(args, "-pass", "1", "-passlogfile", prefix, "-an", "-f", "null", os.DevNull)
+}
+
+func buildPass2Args(inputPath, outputPath string, task *models.Task, prefix string) []string {
+	args := []string{"-i", inputPath}
+	args = appendTwoPassSharedArgs(args, task)
+
+	if task.VideoBitrate != 0 {
+		args = append(args, "-b:v", strconv.FormatInt(task.VideoBitrate, 10))
+	}
+
+	if task.AudioCodec != "" {
+		args = append(args, "-c:a", task.AudioCodec)
+	}
+
+	if task.AudioBitrate != 0 {
+		args = append(args, "-b:a", strconv.FormatInt(task.AudioBitrate, 10))
+	}
+
+	return append(args, "-pass", "2", "-passlogfile", prefix, "-f", task.OutputFormat, "-y", outputPath)
+}
+
+// cleanupPassLog removes the stats file(s) a two-pass encode's first pass
+// wrote under prefix.
+func cleanupPassLog(prefix string) {
+	_ = os.Remove(prefix + "-0.log")
+	_ = os.Remove(prefix + "-0.log.mbtree")
+}
+
+// BuildTwoPassCommands builds the pair of ffmpeg invocations needed to
+// two-pass encode a task: the first pass analyzes the source and writes a
+// stats file, discarding audio and output; the second pass reads that stats
+// file and produces the real output. It must only be called after
+// ValidateTask has succeeded and task.RateControl == "two-pass".
+func (s *Service) BuildTwoPassCommands(
+	ctx context.Context,
+	inputPath, outputPath string,
+	task *models.Task,
+) (pass1, pass2 *exec.Cmd, cleanup func(), err error) {
+	if task.RateControl != "two-pass" {
+		return nil, nil, nil, errors.New("task rate control is not two-pass")
+	}
+
+	prefix := passLogPrefix(task)
+
+	// #nosec G204 -- All arguments are validated against whitelists before reaching this point
+	pass1 = exec.CommandContext(ctx, s.cfg.BinaryPath, buildPass1Args(inputPath, task, prefix)...)
+	// #nosec G204 -- All arguments are validated against whitelists before reaching this point
+	pass2 = exec.CommandContext(ctx, s.cfg.BinaryPath, buildPass2Args(inputPath, outputPath, task, prefix)...)
+
+	cleanup = func() {
+		cleanupPassLog(prefix)
+	}
+
+	return pass1, pass2, cleanup, nil
+}
+
+// RunCommand runs task's ffmpeg command synchronously against inputPath and
+// outputPath, blocking until it completes. Two-pass tasks transparently run
+// both passes in sequence instead of the single command BuildCommand would
+// produce. It must only be called after ValidateTask has succeeded.
+func (s *Service) RunCommand(ctx context.Context, inputPath, outputPath string, task *models.Task) error {
+	if task.RateControl != "two-pass" {
+		cmd := s.BuildCommand(ctx, inputPath, outputPath, task)
+
+		return errors.Wrap(cmd.Run(), "ffmpeg command failed")
+	}
+
+	pass1, pass2, cleanup, err := s.BuildTwoPassCommands(ctx, inputPath, outputPath, task)
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	if err := pass1.Run(); err != nil {
+		return errors.Wrap(err, "two-pass: first pass failed")
+	}
+
+	if err := pass2.Run(); err != nil {
+		return errors.Wrap(err, "two-pass: second pass failed")
+	}
+
+	return nil
+}
+
+// submitTwoPass runs a two-pass task's pair of ffmpeg invocations through
+// the pool in sequence, so both passes get the pool's fair scheduling,
+// the reaper's liveness tracking, and live progress like any other job.
+// A successful pass-1 completion is not relayed as a Result on the returned
+// channel. The stream ends with exactly one Done=true Result: pass 2's, or
+// the error from a failed pass 1 or a rejected pass-2 submission.
+func (s *Service) submitTwoPass(
+	ctx context.Context,
+	clientName, inputPath, outputPath string,
+	task *models.Task,
+) (<-chan pool.Result, error) {
+	prefix := passLogPrefix(task)
+
+	pass1Results, err := s.pool.Submit(ctx, clientName, func(ctx context.Context) *exec.Cmd {
+		// #nosec G204 -- All arguments are validated against whitelists before reaching this point
+		return exec.CommandContext(ctx, s.cfg.BinaryPath, buildPass1Args(inputPath, task, prefix)...)
+	}, s.jobHooks(task))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan pool.Result, 8)
+
+	go func() {
+		defer close(out)
+		defer cleanupPassLog(prefix)
+
+		for r := range pass1Results {
+			if r.Done {
+				if r.Err != nil {
+					out <- pool.Result{Err: errors.Wrap(r.Err, "two-pass: first pass failed"), Done: true}
+
+					return
+				}
+
+				break
+			}
+
+			out <- r
+		}
+
+		pass2Results, err := s.pool.Submit(ctx, clientName, func(ctx context.Context) *exec.Cmd {
+			// #nosec G204 -- All arguments are validated against whitelists before reaching this point
+			return exec.CommandContext(ctx, s.cfg.BinaryPath, buildPass2Args(inputPath, outputPath, task, prefix)...)
+		}, s.jobHooks(task))
+		if err != nil {
+			out <- pool.Result{Err: err, Done: true}
+
+			return
+		}
+
+		for r := range pass2Results {
+			out <- r
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,200 @@
+package ffmpeg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/config"
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+)
+
+func newRateControlService(t *testing.T) *Service {
+	t.Helper()
+
+	cfg := &config.FFmpegConfig{
+		BinaryPath:           "/usr/bin/ffmpeg",
+		AllowedOutputFormats: []string{"mp4"},
+		AllowedVideoCodecs:   []string{"libx264", "copy"},
+		AllowedAudioCodecs:   []string{"aac"},
+		AllowedPresets:       []string{"medium"},
+		MaxWidth:             3840,
+		MaxHeight:            2160,
+		MaxFramerate:         120,
+		RateControl: config.RateControlConfig{
+			AllowedRateControls: []string{"crf", "cq", "two-pass"},
+			CRFRanges: map[string]config.CRFRange{
+				"libx264": {Min: 0, Max: 51},
+			},
+		},
+	}
+
+	return NewService(cfg, &config.ProcessingConfig{WorkerCount: 1, GlobalMaxParallelTasks: 1})
+}
+
+func TestValidateRateControlCRFRange(t *testing.T) {
+	svc := newRateControlService(t)
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+		RateControl:  "crf",
+		CRF:          52,
+	})
+	if err == nil {
+		t.Fatal("expected error for out-of-range CRF")
+	}
+
+	err = svc.ValidateTask(&models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+		RateControl:  "crf",
+		CRF:          23,
+	})
+	if err != nil {
+		t.Errorf("unexpected error for in-range CRF: %v", err)
+	}
+}
+
+func TestValidateRateControlRejectsCRFWithCopyCodec(t *testing.T) {
+	svc := newRateControlService(t)
+
+	err := svc.ValidateTask(&models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "copy",
+		RateControl:  "crf",
+		CRF:          23,
+	})
+	if err == nil {
+		t.Fatal("expected error for crf combined with copy codec")
+	}
+}
+
+func TestBuildCommandArgsCQ(t *testing.T) {
+	task := &models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "h264_nvenc",
+		RateControl:  "cq",
+		CRF:          19,
+	}
+
+	got := buildCommandArgs("/tmp/in.mp4", "/tmp/out.mp4", task, task.VideoCodec, config.StreamingConfig{}, config.HardwareAccelConfig{})
+
+	joined := strings.Join(got, " ")
+	if !strings.Contains(joined, "-cq 19") {
+		t.Errorf("expected -cq 19 in args, got %q", joined)
+	}
+}
+
+func TestBuildCommandArgsCRF(t *testing.T) {
+	task := &models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+		VideoBitrate: 2000000,
+		RateControl:  "crf",
+		CRF:          23,
+	}
+
+	got := buildCommandArgs("/tmp/in.mp4", "/tmp/out.mp4", task, task.VideoCodec, config.StreamingConfig{}, config.HardwareAccelConfig{})
+
+	joined := strings.Join(got, " ")
+	if !strings.Contains(joined, "-crf 23") {
+		t.Errorf("expected -crf 23 in args, got %q", joined)
+	}
+
+	if strings.Contains(joined, "-b:v") {
+		t.Errorf("did not expect -b:v alongside crf, got %q", joined)
+	}
+}
+
+func TestBuildTwoPassCommands(t *testing.T) {
+	svc := newRateControlService(t)
+
+	task := &models.Task{
+		ID:           "task-1",
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+		VideoBitrate: 2000000,
+		AudioCodec:   "aac",
+		RateControl:  "two-pass",
+		Width:        1280,
+		Height:       720,
+		Framerate:    30,
+		Preset:       "medium",
+	}
+
+	pass1, pass2, cleanup, err := svc.BuildTwoPassCommands(context.Background(), "/tmp/in.mp4", "/tmp/out.mp4", task)
+	if err != nil {
+		t.Fatalf("BuildTwoPassCommands() error = %v", err)
+	}
+
+	defer cleanup()
+
+	pass1Joined := strings.Join(pass1.Args, " ")
+	pass2Joined := strings.Join(pass2.Args, " ")
+
+	if !strings.Contains(pass1Joined, "-pass 1") {
+		t.Errorf("pass1 args missing -pass 1: %v", pass1.Args)
+	}
+
+	if !strings.Contains(pass2Joined, "-pass 2") {
+		t.Errorf("pass2 args missing -pass 2: %v", pass2.Args)
+	}
+
+	for _, joined := range []string{pass1Joined, pass2Joined} {
+		for _, want := range []string{"-s 1280x720", "-r 30", "-preset medium"} {
+			if !strings.Contains(joined, want) {
+				t.Errorf("pass args = %q, missing %q; both passes must share encode settings", joined, want)
+			}
+		}
+	}
+}
+
+func TestBuildCommandArgsTwoPassOmitsBitrate(t *testing.T) {
+	task := &models.Task{
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+		VideoBitrate: 2000000,
+		RateControl:  "two-pass",
+	}
+
+	got := buildCommandArgs("/tmp/in.mp4", "/tmp/out.mp4", task, task.VideoCodec, config.StreamingConfig{}, config.HardwareAccelConfig{})
+
+	if strings.Contains(strings.Join(got, " "), "-b:v") {
+		t.Errorf("buildCommandArgs() for a two-pass task should not emit -b:v, got %v; two-pass must go through Submit or RunCommand", got)
+	}
+}
+
+func TestSubmitRoutesTwoPassAsSingleResultStream(t *testing.T) {
+	svc := newRateControlService(t)
+	svc.cfg.BinaryPath = "/bin/echo"
+
+	task := &models.Task{
+		ID:           "task-submit-twopass",
+		OutputFormat: "mp4",
+		VideoCodec:   "libx264",
+		VideoBitrate: 2000000,
+		RateControl:  "two-pass",
+	}
+
+	results, err := svc.Submit(context.Background(), "client-a", "/tmp/in.mp4", "/tmp/out.mp4", task)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	var doneCount int
+
+	for r := range results {
+		if r.Done {
+			doneCount++
+
+			if r.Err != nil {
+				t.Errorf("unexpected job error: %v", r.Err)
+			}
+		}
+	}
+
+	if doneCount != 1 {
+		t.Errorf("got %d Done results, want exactly 1 for a two-pass task submitted as one job", doneCount)
+	}
+}
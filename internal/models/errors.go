@@ -0,0 +1,8 @@
+package models
+
+import "errors"
+
+// ErrInvalidParameter is wrapped by config and task validation errors to
+// mark a failure as caused by bad caller input rather than an internal or
+// environmental fault.
+var ErrInvalidParameter = errors.New("invalid parameter")
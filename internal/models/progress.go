@@ -0,0 +1,10 @@
+package models
+
+// Progress is a snapshot of an in-flight ffmpeg encode, parsed from the
+// key=value blocks ffmpeg emits on "-progress pipe:1".
+type Progress struct {
+	PercentComplete float64 `json:"percent_complete"`
+	Speed           float64 `json:"speed"`
+	ETA             string  `json:"eta,omitempty"`
+	CurrentFPS      float64 `json:"current_fps"`
+}
@@ -10,6 +10,10 @@ const (
 	StatusProcessing   TaskStatus = 2
 	StatusCompleted    TaskStatus = 3
 	StatusFailed       TaskStatus = 4
+	StatusCanceled     TaskStatus = 5
+	// StatusDeadLetter is set once a task has exhausted its retry attempts
+	// and requires operator attention instead of being retried again.
+	StatusDeadLetter TaskStatus = 6
 )
 
 func (s TaskStatus) OneOf(statuses ...TaskStatus) bool {
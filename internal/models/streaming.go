@@ -0,0 +1,20 @@
+package models
+
+// OutputMode selects whether a task produces a single output file or a
+// segmented adaptive-streaming package.
+type OutputMode string
+
+const (
+	OutputModeFile OutputMode = "file"
+	OutputModeHLS  OutputMode = "hls"
+	OutputModeDASH OutputMode = "dash"
+)
+
+// BitrateRung describes one variant of an adaptive bitrate ladder used when
+// OutputMode is OutputModeHLS or OutputModeDASH.
+type BitrateRung struct {
+	Width        int   `json:"width"`
+	Height       int   `json:"height"`
+	VideoBitrate int64 `json:"video_bitrate"`
+	AudioBitrate int64 `json:"audio_bitrate,omitempty"`
+}
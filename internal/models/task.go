@@ -17,6 +17,36 @@ type Task struct {
 	Width          int        `gorm:"column:width;type:integer"`
 	Framerate      int        `gorm:"column:framerate;type:integer"`
 	Preset         string     `gorm:"column:preset;type:text"`
+
+	OutputMode       OutputMode    `gorm:"column:output_mode;not null;type:text;default:'file'"`
+	SegmentDuration  int           `gorm:"column:segment_duration;type:integer"`
+	PlaylistType     string        `gorm:"column:playlist_type;type:text"`
+	RenditionLadder  []BitrateRung `gorm:"column:rendition_ladder;type:text;serializer:json"`
+	EncryptionKeyURI string        `gorm:"column:encryption_key_uri;type:text"`
+
+	Thumbnails *ThumbnailSpec `gorm:"column:thumbnails;type:text;serializer:json"`
+
+	RateControl string `gorm:"column:rate_control;type:text"`
+	CRF         int    `gorm:"column:crf;type:integer"`
+
+	HWAccel          string `gorm:"column:hw_accel;type:text"`
+	PreferHW         bool   `gorm:"column:prefer_hw;not null;default:false"`
+	ActualVideoCodec string `gorm:"column:actual_video_codec;type:text"`
+
+	SubtitleTracks []SubtitleTrack `gorm:"column:subtitle_tracks;type:text;serializer:json"`
+	AudioTracks    []AudioTrack    `gorm:"column:audio_tracks;type:text;serializer:json"`
+
+	Progress *Progress `gorm:"column:progress;type:text;serializer:json"`
+
+	InputURI  string `gorm:"column:input_uri;type:text"`
+	OutputURI string `gorm:"column:output_uri;type:text"`
+
+	// Priority orders a task within the worker pool's scheduling queue;
+	// higher values run first. Tasks of equal priority run in FIFO order.
+	Priority int `gorm:"column:priority;not null;default:0"`
+	// Attempts counts how many times the worker pool has tried to run this
+	// task, including the current one. It drives the dead-letter threshold.
+	Attempts int `gorm:"column:attempts;not null;default:0"`
 }
 
 func (Task) TableName() string {
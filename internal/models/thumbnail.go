@@ -0,0 +1,15 @@
+package models
+
+// ThumbnailSpec configures generation of a sprite sheet of still thumbnails
+// (used by video-scrubbing UIs) alongside a task's main transcode.
+type ThumbnailSpec struct {
+	// Interval is the spacing between captured frames, in seconds.
+	Interval int `json:"interval"`
+	// Width and Height are the dimensions of a single sprite tile.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Count is the total number of tiles to capture.
+	Count int `json:"count"`
+	// SpriteColumns is the number of tiles per row in the sprite sheet.
+	SpriteColumns int `json:"sprite_columns"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// SubtitleTrack selects one subtitle stream from the input and how it
+// should be carried on the output: muxed as its own stream, or burned
+// directly into the video via an overlay filter.
+type SubtitleTrack struct {
+	Index    int    `json:"index"`
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+	Burn     bool   `json:"burn,omitempty"`
+}
+
+// AudioTrack selects one additional audio stream from the input to carry on
+// the output, alongside Task's primary AudioCodec/AudioBitrate.
+type AudioTrack struct {
+	Index    int    `json:"index"`
+	Codec    string `json:"codec"`
+	Bitrate  int64  `json:"bitrate,omitempty"`
+	Language string `json:"language,omitempty"`
+}
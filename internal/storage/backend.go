@@ -0,0 +1,67 @@
+// Package storage abstracts where task input and output files live, so the
+// ffmpeg worker can run against local disk or an S3-compatible object store
+// without caring which.
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Info is the metadata Stat returns for an object.
+type Info struct {
+	Size         int64
+	LastModified time.Time
+}
+
+// Backend is implemented by each storage provider (local disk, S3/MinIO).
+// Keys are provider-relative paths, e.g. "tasks/<id>/input.mp4".
+type Backend interface {
+	// Put uploads the file at localPath to key.
+	Put(ctx context.Context, key, localPath string) error
+	// Get downloads key to the file at localPath.
+	Get(ctx context.Context, key, localPath string) error
+	// Stat returns metadata for key.
+	Stat(ctx context.Context, key string) (Info, error)
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+	// PresignedURL returns a time-limited URL clients can use to fetch key
+	// directly, bypassing the API server.
+	PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// keyLister is implemented by backends that can enumerate their own keys,
+// which the cleanup routine needs but which isn't part of the public
+// Backend contract every caller needs.
+type keyLister interface {
+	ListKeys(ctx context.Context) ([]string, error)
+}
+
+// CleanupOlderThan deletes every object in backend whose LastModified is
+// older than maxAge. Backends that don't support listing are left alone.
+func CleanupOlderThan(ctx context.Context, backend Backend, maxAge time.Duration) error {
+	lister, ok := backend.(keyLister)
+	if !ok {
+		return nil
+	}
+
+	keys, err := lister.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+
+	for _, key := range keys {
+		info, err := backend.Stat(ctx, key)
+		if err != nil {
+			continue
+		}
+
+		if info.LastModified.Before(cutoff) {
+			_ = backend.Delete(ctx, key)
+		}
+	}
+
+	return nil
+}
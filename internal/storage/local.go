@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LocalBackend stores objects as files under a base directory on local
+// disk.
+type LocalBackend struct {
+	baseDir string
+}
+
+// NewLocalBackend builds a LocalBackend rooted at baseDir.
+func NewLocalBackend(baseDir string) *LocalBackend {
+	return &LocalBackend{baseDir: baseDir}
+}
+
+func (b *LocalBackend) path(key string) string {
+	return filepath.Join(b.baseDir, filepath.FromSlash(key))
+}
+
+func (b *LocalBackend) Put(_ context.Context, key, localPath string) error {
+	dst := b.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return errors.Wrap(err, "failed to create destination directory")
+	}
+
+	return copyFile(localPath, dst)
+}
+
+func (b *LocalBackend) Get(_ context.Context, key, localPath string) error {
+	return copyFile(b.path(key), localPath)
+}
+
+func (b *LocalBackend) Stat(_ context.Context, key string) (Info, error) {
+	info, err := os.Stat(b.path(key))
+	if err != nil {
+		return Info{}, errors.Wrap(err, "failed to stat object")
+	}
+
+	return Info{Size: info.Size(), LastModified: info.ModTime()}, nil
+}
+
+func (b *LocalBackend) Delete(_ context.Context, key string) error {
+	if err := os.Remove(b.path(key)); err != nil {
+		return errors.Wrap(err, "failed to delete object")
+	}
+
+	return nil
+}
+
+func (b *LocalBackend) PresignedURL(_ context.Context, _ string, _ time.Duration) (string, error) {
+	return "", errors.New("local backend does not support presigned URLs")
+}
+
+func (b *LocalBackend) ListKeys(_ context.Context) ([]string, error) {
+	var keys []string
+
+	err := filepath.WalkDir(b.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(b.baseDir, path)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+
+	return keys, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src) //nolint:gosec // paths are derived from validated task storage keys
+	if err != nil {
+		return errors.Wrap(err, "failed to open source file")
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return errors.Wrap(err, "failed to create destination directory")
+	}
+
+	out, err := os.Create(dst) //nolint:gosec // paths are derived from validated task storage keys
+	if err != nil {
+		return errors.Wrap(err, "failed to create destination file")
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Wrap(err, "failed to copy file")
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLocalBackendPutGetDelete(t *testing.T) {
+	baseDir := t.TempDir()
+	backend := NewLocalBackend(baseDir)
+
+	srcDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "input.mp4")
+
+	if err := os.WriteFile(srcFile, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("failed to write source file: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := backend.Put(ctx, "tasks/1/output.mp4", srcFile); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	info, err := backend.Stat(ctx, "tasks/1/output.mp4")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+
+	if info.Size != 5 {
+		t.Errorf("Stat().Size = %d, want 5", info.Size)
+	}
+
+	dstFile := filepath.Join(srcDir, "downloaded.mp4")
+	if err := backend.Get(ctx, "tasks/1/output.mp4", dstFile); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	got, err := os.ReadFile(dstFile)
+	if err != nil {
+		t.Fatalf("failed to read downloaded file: %v", err)
+	}
+
+	if string(got) != "hello" {
+		t.Errorf("downloaded content = %q, want %q", got, "hello")
+	}
+
+	if err := backend.Delete(ctx, "tasks/1/output.mp4"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := backend.Stat(ctx, "tasks/1/output.mp4"); err == nil {
+		t.Error("expected Stat() to error after Delete()")
+	}
+}
+
+func TestLocalBackendPresignedURLUnsupported(t *testing.T) {
+	backend := NewLocalBackend(t.TempDir())
+
+	if _, err := backend.PresignedURL(context.Background(), "key", time.Minute); err == nil {
+		t.Error("expected PresignedURL() to error for local backend")
+	}
+}
+
+func TestCleanupOlderThan(t *testing.T) {
+	baseDir := t.TempDir()
+	backend := NewLocalBackend(baseDir)
+
+	srcFile := filepath.Join(t.TempDir(), "input.mp4")
+	if err := os.WriteFile(srcFile, []byte("hi"), 0o600); err != nil {
+		t.Fatalf("failed to write source file: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := backend.Put(ctx, "old.mp4", srcFile); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	oldTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(baseDir, "old.mp4"), oldTime, oldTime); err != nil {
+		t.Fatalf("failed to backdate file: %v", err)
+	}
+
+	if err := CleanupOlderThan(ctx, backend, time.Minute); err != nil {
+		t.Fatalf("CleanupOlderThan() error = %v", err)
+	}
+
+	if _, err := backend.Stat(ctx, "old.mp4"); err == nil {
+		t.Error("expected old.mp4 to be cleaned up")
+	}
+}
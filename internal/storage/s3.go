@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pkg/errors"
+)
+
+// S3Config holds the connection details for an S3-compatible object store
+// (AWS S3, MinIO, etc).
+type S3Config struct {
+	Endpoint     string
+	Bucket       string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool
+}
+
+// S3Backend stores objects in an S3-compatible bucket.
+type S3Backend struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Backend builds an S3Backend from cfg. It does not verify the bucket
+// is reachable; call CheckReachable for that.
+func NewS3Backend(ctx context.Context, cfg S3Config) (*S3Backend, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithRegion(cfg.Region),
+		awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, ""),
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load AWS config")
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = &cfg.Endpoint
+		}
+
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+
+	return &S3Backend{client: client, bucket: cfg.Bucket}, nil
+}
+
+// CheckReachable verifies the configured bucket exists and is reachable,
+// intended to be called once at startup.
+func (b *S3Backend) CheckReachable(ctx context.Context) error {
+	_, err := b.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: &b.bucket})
+	if err != nil {
+		return errors.Wrapf(err, "bucket %q is not reachable", b.bucket)
+	}
+
+	return nil
+}
+
+func (b *S3Backend) Put(ctx context.Context, key, localPath string) error {
+	f, err := os.Open(localPath) //nolint:gosec // paths are derived from validated task storage keys
+	if err != nil {
+		return errors.Wrap(err, "failed to open local file")
+	}
+	defer f.Close()
+
+	_, err = b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: &b.bucket,
+		Key:    &key,
+		Body:   f,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+
+	return nil
+}
+
+func (b *S3Backend) Get(ctx context.Context, key, localPath string) error {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &b.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to get object")
+	}
+	defer out.Body.Close()
+
+	f, err := os.Create(localPath) //nolint:gosec // paths are derived from validated task storage keys
+	if err != nil {
+		return errors.Wrap(err, "failed to create local file")
+	}
+	defer f.Close()
+
+	if _, err := f.ReadFrom(out.Body); err != nil {
+		return errors.Wrap(err, "failed to write object to local file")
+	}
+
+	return f.Close()
+}
+
+func (b *S3Backend) Stat(ctx context.Context, key string) (Info, error) {
+	out, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &b.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return Info{}, errors.Wrap(err, "failed to stat object")
+	}
+
+	info := Info{}
+	if out.ContentLength != nil {
+		info.Size = *out.ContentLength
+	}
+
+	if out.LastModified != nil {
+		info.LastModified = *out.LastModified
+	}
+
+	return info, nil
+}
+
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &b.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to delete object")
+	}
+
+	return nil
+}
+
+func (b *S3Backend) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(b.client)
+
+	req, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: &b.bucket,
+		Key:    &key,
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to presign URL")
+	}
+
+	return req.URL, nil
+}
+
+func (b *S3Backend) ListKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{Bucket: &b.bucket})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list objects")
+		}
+
+		for _, obj := range page.Contents {
+			if obj.Key != nil {
+				keys = append(keys, *obj.Key)
+			}
+		}
+	}
+
+	return keys, nil
+}
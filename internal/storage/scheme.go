@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SchemeBackend fetches and publishes objects addressed by a full URI
+// (e.g. "s3://bucket/key", "file:///tmp/in.mp4", "https://host/in.mp4"),
+// as opposed to Backend's provider-relative keys.
+type SchemeBackend interface {
+	// Fetch downloads uri to a local temp file and returns its path along
+	// with a cleanup func the caller must invoke once done with it.
+	Fetch(ctx context.Context, uri string) (localPath string, cleanup func(), err error)
+	// Publish uploads the file at localPath to uri.
+	Publish(ctx context.Context, localPath, uri string) error
+}
+
+// SchemeRegistry dispatches Fetch/Publish calls to the SchemeBackend
+// registered for a URI's scheme, so callers can treat task input/output
+// locations uniformly regardless of which backend serves them.
+type SchemeRegistry struct {
+	backends map[string]SchemeBackend
+}
+
+// NewSchemeRegistry builds an empty SchemeRegistry; use Register to add
+// backends for the schemes the deployment needs to support.
+func NewSchemeRegistry() *SchemeRegistry {
+	return &SchemeRegistry{backends: make(map[string]SchemeBackend)}
+}
+
+// Register associates scheme (e.g. "s3", without "://") with backend,
+// overwriting any backend previously registered for that scheme.
+func (r *SchemeRegistry) Register(scheme string, backend SchemeBackend) {
+	r.backends[scheme] = backend
+}
+
+func (r *SchemeRegistry) Fetch(ctx context.Context, uri string) (string, func(), error) {
+	backend, err := r.backendFor(uri)
+	if err != nil {
+		return "", nil, err
+	}
+
+	localPath, cleanup, err := backend.Fetch(ctx, uri)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to fetch %q", uri)
+	}
+
+	return localPath, cleanup, nil
+}
+
+func (r *SchemeRegistry) Publish(ctx context.Context, localPath, uri string) error {
+	backend, err := r.backendFor(uri)
+	if err != nil {
+		return err
+	}
+
+	if err := backend.Publish(ctx, localPath, uri); err != nil {
+		return errors.Wrapf(err, "failed to publish to %q", uri)
+	}
+
+	return nil
+}
+
+func (r *SchemeRegistry) backendFor(uri string) (SchemeBackend, error) {
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok {
+		return nil, errors.Errorf("uri %q has no scheme", uri)
+	}
+
+	backend, ok := r.backends[scheme]
+	if !ok {
+		return nil, errors.Errorf("no storage backend registered for scheme %q", scheme)
+	}
+
+	return backend, nil
+}
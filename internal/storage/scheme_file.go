@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"strings"
+)
+
+// FileSchemeBackend serves "file://" URIs directly off local disk, with no
+// copy on Fetch and a plain file copy on Publish.
+type FileSchemeBackend struct{}
+
+// NewFileSchemeBackend builds a FileSchemeBackend.
+func NewFileSchemeBackend() *FileSchemeBackend {
+	return &FileSchemeBackend{}
+}
+
+func (b *FileSchemeBackend) Fetch(_ context.Context, uri string) (string, func(), error) {
+	return filePath(uri), func() {}, nil
+}
+
+func (b *FileSchemeBackend) Publish(_ context.Context, localPath, uri string) error {
+	return copyFile(localPath, filePath(uri))
+}
+
+func filePath(uri string) string {
+	_, path, _ := strings.Cut(uri, "://")
+
+	return path
+}
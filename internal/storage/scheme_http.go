@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPSchemeBackend fetches "http://" and "https://" URIs over plain HTTP;
+// it is read-only, since there's no generic way to publish to an arbitrary
+// URL.
+type HTTPSchemeBackend struct {
+	client *http.Client
+}
+
+// NewHTTPSchemeBackend builds an HTTPSchemeBackend using client, or
+// http.DefaultClient if client is nil.
+func NewHTTPSchemeBackend(client *http.Client) *HTTPSchemeBackend {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPSchemeBackend{client: client}
+}
+
+func (b *HTTPSchemeBackend) Fetch(ctx context.Context, uri string) (string, func(), error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to build request")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to fetch uri")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, errors.Errorf("unexpected status %d fetching %q", resp.StatusCode, uri)
+	}
+
+	f, err := os.CreateTemp("", "ffmpegbox-http-fetch-*")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to create temp file")
+	}
+	defer f.Close()
+
+	localPath := f.Name()
+	cleanup := func() { _ = os.Remove(localPath) }
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		cleanup()
+
+		return "", nil, errors.Wrap(err, "failed to write response body")
+	}
+
+	return localPath, cleanup, nil
+}
+
+func (b *HTTPSchemeBackend) Publish(_ context.Context, _, uri string) error {
+	return errors.Errorf("http backend does not support publishing, uri %q", uri)
+}
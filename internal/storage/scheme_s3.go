@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// S3SchemeBackend serves "s3://<bucket>/<key>" URIs via an underlying
+// S3Backend configured for a single bucket; it rejects URIs addressed to
+// any other bucket.
+type S3SchemeBackend struct {
+	backend *S3Backend
+	bucket  string
+}
+
+// NewS3SchemeBackend wraps backend, which must be configured for bucket.
+func NewS3SchemeBackend(backend *S3Backend, bucket string) *S3SchemeBackend {
+	return &S3SchemeBackend{backend: backend, bucket: bucket}
+}
+
+func (b *S3SchemeBackend) Fetch(ctx context.Context, uri string) (string, func(), error) {
+	key, err := b.keyFor(uri)
+	if err != nil {
+		return "", nil, err
+	}
+
+	f, err := os.CreateTemp("", "ffmpegbox-s3-fetch-*")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to create temp file")
+	}
+	localPath := f.Name()
+	f.Close()
+
+	cleanup := func() { _ = os.Remove(localPath) }
+
+	if err := b.backend.Get(ctx, key, localPath); err != nil {
+		cleanup()
+
+		return "", nil, err
+	}
+
+	return localPath, cleanup, nil
+}
+
+func (b *S3SchemeBackend) Publish(ctx context.Context, localPath, uri string) error {
+	key, err := b.keyFor(uri)
+	if err != nil {
+		return err
+	}
+
+	return b.backend.Put(ctx, key, localPath)
+}
+
+func (b *S3SchemeBackend) keyFor(uri string) (string, error) {
+	_, rest, _ := strings.Cut(uri, "://")
+
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket != b.bucket {
+		return "", errors.Errorf("uri %q targets bucket %q, backend is configured for %q", uri, bucket, b.bucket)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchemeRegistryFetchPublishFile(t *testing.T) {
+	registry := NewSchemeRegistry()
+	registry.Register("file", NewFileSchemeBackend())
+
+	srcDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "input.mp4")
+
+	if err := os.WriteFile(srcFile, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("failed to write source file: %v", err)
+	}
+
+	ctx := context.Background()
+
+	localPath, cleanup, err := registry.Fetch(ctx, "file://"+srcFile)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	defer cleanup()
+
+	if localPath != srcFile {
+		t.Errorf("Fetch() localPath = %q, want %q", localPath, srcFile)
+	}
+
+	dstFile := filepath.Join(srcDir, "output.mp4")
+	if err := registry.Publish(ctx, srcFile, "file://"+dstFile); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	got, err := os.ReadFile(dstFile)
+	if err != nil {
+		t.Fatalf("failed to read published file: %v", err)
+	}
+
+	if string(got) != "hello" {
+		t.Errorf("published content = %q, want %q", got, "hello")
+	}
+}
+
+func TestSchemeRegistryUnregisteredScheme(t *testing.T) {
+	registry := NewSchemeRegistry()
+
+	if _, _, err := registry.Fetch(context.Background(), "s3://bucket/key"); err == nil {
+		t.Error("expected error fetching an unregistered scheme")
+	}
+}
+
+func TestHTTPSchemeBackendFetchAndRejectPublish(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	backend := NewHTTPSchemeBackend(nil)
+
+	localPath, cleanup, err := backend.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	defer cleanup()
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("failed to read fetched file: %v", err)
+	}
+
+	if string(got) != "hello" {
+		t.Errorf("fetched content = %q, want %q", got, "hello")
+	}
+
+	if err := backend.Publish(context.Background(), localPath, srv.URL); err == nil {
+		t.Error("expected Publish() to error for http backend")
+	}
+}
+
+func TestS3SchemeBackendRejectsBucketMismatch(t *testing.T) {
+	backend := NewS3SchemeBackend(&S3Backend{}, "configured-bucket")
+
+	if _, _, err := backend.Fetch(context.Background(), "s3://other-bucket/key.mp4"); err == nil {
+		t.Error("expected error fetching a URI targeting a different bucket")
+	}
+}
@@ -0,0 +1,95 @@
+package workerpool
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// cpuSample is one reading of the aggregate "cpu" line from /proc/stat, in
+// USER_HZ ticks.
+type cpuSample struct {
+	idle  uint64
+	total uint64
+}
+
+// cpuSampler estimates host CPU utilization from successive /proc/stat
+// readings, so the pool can hold off starting new work while the host is
+// already saturated.
+type cpuSampler struct {
+	mu   sync.Mutex
+	prev cpuSample
+	has  bool
+}
+
+// Percent returns the CPU utilization observed since the previous call, as a
+// percentage in [0, 100]. The first call always returns 0, since utilization
+// requires two samples.
+func (s *cpuSampler) Percent() (float64, error) {
+	cur, err := readProcStat()
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, has := s.prev, s.has
+	s.prev, s.has = cur, true
+
+	if !has {
+		return 0, nil
+	}
+
+	totalDelta := cur.total - prev.total
+	if totalDelta == 0 {
+		return 0, nil
+	}
+
+	idleDelta := cur.idle - prev.idle
+
+	return float64(totalDelta-idleDelta) / float64(totalDelta) * 100, nil
+}
+
+// readProcStat parses the aggregate "cpu  " line of /proc/stat, whose fields
+// are user, nice, system, idle, iowait, irq, softirq, steal, ... in USER_HZ
+// ticks since boot.
+func readProcStat() (cpuSample, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return cpuSample{}, errors.Wrap(err, "failed to open /proc/stat")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		var total uint64
+
+		for _, f := range fields[1:] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return cpuSample{}, errors.Wrap(err, "failed to parse /proc/stat field")
+			}
+
+			total += v
+		}
+
+		idle, err := strconv.ParseUint(fields[4], 10, 64)
+		if err != nil {
+			return cpuSample{}, errors.Wrap(err, "failed to parse /proc/stat idle field")
+		}
+
+		return cpuSample{idle: idle, total: total}, nil
+	}
+
+	return cpuSample{}, errors.New("/proc/stat has no aggregate cpu line")
+}
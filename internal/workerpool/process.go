@@ -0,0 +1,104 @@
+package workerpool
+
+import (
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// stderrTailLimit bounds how much stderr CmdHandle retains for transient-
+// failure classification; ffmpeg can be extremely chatty on long jobs.
+const stderrTailLimit = 4096
+
+// ProcessHandle is the subset of a running ffmpeg invocation the pool needs
+// in order to wait for it and, if the task's context is canceled, shut it
+// down: Graceful first (ffmpeg finalizes the output file and exits cleanly),
+// escalating to Kill if it doesn't exit in time.
+type ProcessHandle interface {
+	// Wait blocks until the process has exited.
+	Wait() error
+	// Graceful asks the process to stop on its own, e.g. by writing "q" to
+	// ffmpeg's stdin.
+	Graceful() error
+	// Kill forcibly terminates the process.
+	Kill() error
+	// Stderr returns a tail of the process's stderr output, used to classify
+	// whether a failure was transient.
+	Stderr() string
+}
+
+// RunFunc starts the ffmpeg invocation for a queued task (typically by
+// calling Service.BuildCommand and handing the result to NewCmdHandle) and
+// returns a handle the pool uses to observe and, if needed, cancel it.
+type RunFunc func(taskID string, attempt int) (ProcessHandle, error)
+
+// CmdHandle adapts an *exec.Cmd to ProcessHandle, wiring up a stdin pipe for
+// graceful shutdown and a bounded stderr tail for failure classification.
+type CmdHandle struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu   sync.Mutex
+	tail []byte
+}
+
+// NewCmdHandle starts cmd and returns a handle for it. cmd must not already
+// have Stdin or Stderr set; NewCmdHandle attaches both itself.
+func NewCmdHandle(cmd *exec.Cmd) (*CmdHandle, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to attach stdin pipe")
+	}
+
+	h := &CmdHandle{cmd: cmd, stdin: stdin}
+	cmd.Stderr = h
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "failed to start ffmpeg")
+	}
+
+	return h, nil
+}
+
+// Write implements io.Writer so CmdHandle can be used directly as cmd.Stderr,
+// retaining only the last stderrTailLimit bytes seen.
+func (h *CmdHandle) Write(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.tail = append(h.tail, p...)
+	if len(h.tail) > stderrTailLimit {
+		h.tail = h.tail[len(h.tail)-stderrTailLimit:]
+	}
+
+	return len(p), nil
+}
+
+func (h *CmdHandle) Wait() error {
+	return h.cmd.Wait()
+}
+
+// Graceful sends ffmpeg "q\n" on stdin, the documented way to ask it to
+// finish the current output cleanly rather than leaving a truncated file.
+func (h *CmdHandle) Graceful() error {
+	_, err := io.WriteString(h.stdin, "q\n")
+
+	return err
+}
+
+func (h *CmdHandle) Kill() error {
+	if h.cmd.Process == nil {
+		return nil
+	}
+
+	return h.cmd.Process.Kill()
+}
+
+func (h *CmdHandle) Stderr() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return string(h.tail)
+}
@@ -0,0 +1,63 @@
+package workerpool
+
+import "container/heap"
+
+// item is one task waiting to run. Higher Priority runs first; items of
+// equal priority run in the order they were enqueued (seq breaks ties).
+type item struct {
+	taskID   string
+	attempt  int
+	priority int
+	seq      int64
+	index    int
+}
+
+// priorityQueue is a max-heap on (priority, -seq), giving strict priority
+// order with FIFO tie-breaking.
+type priorityQueue []*item
+
+func (q priorityQueue) Len() int { return len(q) }
+
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q priorityQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *priorityQueue) Push(x any) {
+	it, _ := x.(*item)
+	it.index = len(*q)
+	*q = append(*q, it)
+}
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.index = -1
+	*q = old[:n-1]
+
+	return it
+}
+
+// removeByTaskID removes and returns the queued item for taskID, if any.
+func (q *priorityQueue) removeByTaskID(taskID string) *item {
+	for _, it := range *q {
+		if it.taskID == taskID {
+			heap.Remove(q, it.index)
+
+			return it
+		}
+	}
+
+	return nil
+}
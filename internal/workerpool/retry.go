@@ -0,0 +1,41 @@
+package workerpool
+
+import (
+	"regexp"
+	"time"
+)
+
+// transientStderrRe matches ffmpeg stderr substrings that indicate a
+// failure was caused by something outside the task itself (a flaky
+// network mount, a full disk, etc.) and is therefore worth retrying.
+var transientStderrRe = regexp.MustCompile(
+	`(?i)connection reset|no space left|temporary failure|` +
+		`resource temporarily unavailable|i/o timeout|broken pipe`,
+)
+
+// isTransient reports whether a failed task's stderr output looks like a
+// transient error worth retrying, as opposed to a problem with the task's
+// own parameters or input that retrying won't fix.
+func isTransient(stderr string) bool {
+	return transientStderrRe.MatchString(stderr)
+}
+
+// backoff returns the delay before retrying a task's attempt-th attempt
+// (1-indexed), doubling from base and capped at max. A base of 0 disables
+// backoff (retries happen immediately).
+func backoff(attempt int, base, max time.Duration) time.Duration { //nolint:predeclared // max is the clearest name here
+	if base <= 0 {
+		return 0
+	}
+
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := base << (attempt - 1)
+	if delay <= 0 || delay > max {
+		return max
+	}
+
+	return delay
+}
@@ -0,0 +1,361 @@
+// Package workerpool schedules ffmpeg jobs for the db-backed task queue. It
+// runs a fixed number of worker goroutines that pull the highest-priority
+// pending task, run it via a caller-supplied RunFunc, and update the task's
+// status in the GORM database once it finishes, is canceled, or is
+// exhausted of retries.
+package workerpool
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Options configures a Pool's concurrency, CPU backpressure, and retry
+// behavior.
+type Options struct {
+	// MaxConcurrent is the number of worker goroutines running tasks at
+	// once. Must be >= 1.
+	MaxConcurrent int
+	// MaxCPUPercent, if > 0, stops workers from picking up new tasks while
+	// host CPU utilization (sampled from /proc/stat) is above it. Tasks
+	// already running are never preempted.
+	MaxCPUPercent float64
+	// MaxAttempts is the total number of times a task may be run, including
+	// its first attempt, before it is moved to StatusDeadLetter instead of
+	// being retried again. Values <= 1 disable retries.
+	MaxAttempts int
+	// BackoffBase and BackoffMax bound the exponential backoff applied
+	// between retry attempts.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// GracePeriod is how long a canceled task is given to exit after its
+	// RunFunc's Graceful() before the pool escalates to Kill().
+	GracePeriod time.Duration
+}
+
+// cpuCheckInterval is how often a worker re-samples CPU usage while holding
+// off picking up new work because MaxCPUPercent was exceeded.
+const cpuCheckInterval = time.Second
+
+// Pool runs ffmpeg tasks drawn from a priority queue across a fixed number
+// of worker goroutines, persisting each task's terminal status to the GORM
+// database.
+type Pool struct {
+	db   *gorm.DB
+	run  RunFunc
+	opts Options
+	cpu  *cpuSampler
+
+	mu             sync.Mutex
+	queue          priorityQueue
+	seq            int64
+	running        map[string]context.CancelFunc
+	pendingRetries map[string]*time.Timer
+
+	wake     chan struct{}
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New starts a Pool with opts.MaxConcurrent worker goroutines. run is
+// invoked by a worker each time it dequeues a task to run, including
+// retries.
+func New(db *gorm.DB, run RunFunc, opts Options) *Pool {
+	p := &Pool{
+		db:             db,
+		run:            run,
+		opts:           opts,
+		cpu:            &cpuSampler{},
+		running:        make(map[string]context.CancelFunc),
+		pendingRetries: make(map[string]*time.Timer),
+		wake:           make(chan struct{}, 1),
+		shutdown:       make(chan struct{}),
+	}
+
+	for range opts.MaxConcurrent {
+		p.wg.Add(1)
+
+		go p.workerLoop()
+	}
+
+	return p
+}
+
+// Enqueue schedules taskID to run with the given priority; higher priority
+// tasks are dequeued first.
+func (p *Pool) Enqueue(taskID string, priority int) {
+	p.mu.Lock()
+	p.seq++
+	heap.Push(&p.queue, &item{taskID: taskID, attempt: 1, priority: priority, seq: p.seq})
+	p.mu.Unlock()
+
+	p.signal()
+}
+
+// Cancel stops taskID wherever it currently is: a running task has its
+// context canceled, which tells its worker to run the graceful/kill
+// shutdown sequence; a task waiting out its retry backoff has that retry
+// canceled; a queued-but-not-started task is removed from the queue. In the
+// latter two cases the task is marked StatusCanceled directly. It reports
+// whether taskID was found in any of those states.
+func (p *Pool) Cancel(taskID string) bool {
+	p.mu.Lock()
+
+	if cancel, ok := p.running[taskID]; ok {
+		p.mu.Unlock()
+		cancel()
+
+		return true
+	}
+
+	if timer, ok := p.pendingRetries[taskID]; ok {
+		timer.Stop()
+		delete(p.pendingRetries, taskID)
+		p.mu.Unlock()
+
+		_ = p.setStatus(taskID, models.StatusCanceled, "canceled while waiting to retry")
+
+		return true
+	}
+
+	it := p.queue.removeByTaskID(taskID)
+	p.mu.Unlock()
+
+	if it == nil {
+		return false
+	}
+
+	_ = p.setStatus(taskID, models.StatusCanceled, "canceled before it started running")
+
+	return true
+}
+
+// Stats is a point-in-time snapshot of a Pool's queue and running tasks.
+type Stats struct {
+	Queued  int
+	Running int
+}
+
+// Stats returns a snapshot of the Pool's current queue depth and running
+// task count.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return Stats{Queued: len(p.queue), Running: len(p.running)}
+}
+
+// Shutdown stops workers from picking up new tasks and waits for in-flight
+// tasks to finish on their own, canceling them once deadline elapses.
+func (p *Pool) Shutdown(deadline time.Duration) {
+	close(p.shutdown)
+
+	done := make(chan struct{})
+
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(deadline):
+	}
+
+	p.mu.Lock()
+	for _, cancel := range p.running {
+		cancel()
+	}
+	p.mu.Unlock()
+
+	<-done
+}
+
+func (p *Pool) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Pool) workerLoop() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.shutdown:
+			return
+		default:
+		}
+
+		if p.cpuThrottled() {
+			select {
+			case <-time.After(cpuCheckInterval):
+			case <-p.shutdown:
+				return
+			}
+
+			continue
+		}
+
+		it, ctx, cancel := p.nextItem()
+		if it == nil {
+			select {
+			case <-p.wake:
+			case <-p.shutdown:
+				return
+			}
+
+			continue
+		}
+
+		p.runItem(it, ctx, cancel)
+	}
+}
+
+// cpuThrottled reports whether workers should hold off picking up new work
+// because host CPU usage is above Options.MaxCPUPercent. It fails open: a
+// sampling error is treated as "not throttled" rather than stalling the
+// pool.
+func (p *Pool) cpuThrottled() bool {
+	if p.opts.MaxCPUPercent <= 0 {
+		return false
+	}
+
+	pct, err := p.cpu.Percent()
+	if err != nil {
+		return false
+	}
+
+	return pct > p.opts.MaxCPUPercent
+}
+
+// nextItem pops the highest-priority queued item, if any, and registers it
+// as running in the same locked section so Cancel can never observe a gap
+// where a dequeued task is in neither the queue nor the running map.
+func (p *Pool) nextItem() (*item, context.Context, context.CancelFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.queue.Len() == 0 {
+		return nil, nil, nil
+	}
+
+	it, _ := heap.Pop(&p.queue).(*item)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.running[it.taskID] = cancel
+
+	return it, ctx, cancel
+}
+
+func (p *Pool) runItem(it *item, ctx context.Context, cancel context.CancelFunc) {
+	defer func() {
+		p.mu.Lock()
+		delete(p.running, it.taskID)
+		p.mu.Unlock()
+		cancel()
+	}()
+
+	_ = p.setAttempt(it.taskID, it.attempt)
+
+	handle, err := p.run(it.taskID, it.attempt)
+	if err != nil {
+		p.handleFailure(it, "", errors.Wrap(err, "failed to start task"))
+
+		return
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- handle.Wait() }()
+
+	select {
+	case waitErr := <-done:
+		if waitErr == nil {
+			_ = p.setStatus(it.taskID, models.StatusCompleted, "")
+
+			return
+		}
+
+		p.handleFailure(it, handle.Stderr(), waitErr)
+	case <-ctx.Done():
+		p.cancelHandle(handle, done)
+		_ = p.setStatus(it.taskID, models.StatusCanceled, "canceled while running")
+	}
+}
+
+// cancelHandle asks handle to stop gracefully and, if it hasn't exited
+// within Options.GracePeriod, kills it. It waits for the process to exit
+// either way.
+func (p *Pool) cancelHandle(handle ProcessHandle, done <-chan error) {
+	_ = handle.Graceful()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(p.opts.GracePeriod):
+	}
+
+	_ = handle.Kill()
+	<-done
+}
+
+// handleFailure retries it if its failure looks transient and it hasn't
+// exhausted Options.MaxAttempts, otherwise it records a terminal failure.
+func (p *Pool) handleFailure(it *item, stderr string, runErr error) {
+	if isTransient(stderr) && it.attempt < p.opts.MaxAttempts {
+		delay := backoff(it.attempt, p.opts.BackoffBase, p.opts.BackoffMax)
+		nextAttempt := it.attempt + 1
+
+		p.mu.Lock()
+		p.pendingRetries[it.taskID] = time.AfterFunc(delay, func() {
+			p.mu.Lock()
+			if _, ok := p.pendingRetries[it.taskID]; !ok {
+				// Cancel removed this retry after the timer had already fired.
+				p.mu.Unlock()
+
+				return
+			}
+
+			delete(p.pendingRetries, it.taskID)
+			p.seq++
+			heap.Push(&p.queue, &item{taskID: it.taskID, attempt: nextAttempt, priority: it.priority, seq: p.seq})
+			p.mu.Unlock()
+
+			p.signal()
+		})
+		p.mu.Unlock()
+
+		return
+	}
+
+	status := models.StatusFailed
+	if it.attempt >= p.opts.MaxAttempts {
+		status = models.StatusDeadLetter
+	}
+
+	_ = p.setStatus(it.taskID, status, runErr.Error())
+}
+
+func (p *Pool) setAttempt(taskID string, attempt int) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.Task{}).
+			Where("id = ?", taskID).
+			Updates(map[string]any{"status": models.StatusProcessing, "attempts": attempt}).Error
+	})
+}
+
+func (p *Pool) setStatus(taskID string, status models.TaskStatus, errMsg string) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Model(&models.Task{}).
+			Where("id = ?", taskID).
+			Updates(map[string]any{"status": status, "error_message": errMsg}).Error
+	})
+}
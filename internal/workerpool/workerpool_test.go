@@ -0,0 +1,199 @@
+package workerpool
+
+import (
+	"container/heap"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opoccomaxao/ffmpegbox/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.Task{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	return db
+}
+
+func waitForStatus(t *testing.T, db *gorm.DB, taskID string, want models.TaskStatus) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for time.Now().Before(deadline) {
+		var task models.Task
+		if err := db.First(&task, "id = ?", taskID).Error; err != nil {
+			t.Fatalf("failed to load task: %v", err)
+		}
+
+		if task.Status == want {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("status for task %q did not reach %v in time", taskID, want)
+}
+
+// fakeHandle is a ProcessHandle a test controls directly, standing in for a
+// real ffmpeg process.
+type fakeHandle struct {
+	waitErr  chan error
+	stderr   string
+	graceful bool
+	killed   bool
+}
+
+func newFakeHandle(result error) *fakeHandle {
+	h := &fakeHandle{waitErr: make(chan error, 1)}
+	h.waitErr <- result
+
+	return h
+}
+
+func (h *fakeHandle) Wait() error { return <-h.waitErr }
+
+func (h *fakeHandle) Graceful() error {
+	h.graceful = true
+
+	return nil
+}
+
+func (h *fakeHandle) Kill() error {
+	h.killed = true
+
+	return nil
+}
+
+func (h *fakeHandle) Stderr() string { return h.stderr }
+
+func TestPriorityQueueOrdersByPriorityThenFIFO(t *testing.T) {
+	var q priorityQueue
+
+	heap.Push(&q, &item{taskID: "low", priority: 1, seq: 1})
+	heap.Push(&q, &item{taskID: "high-first", priority: 5, seq: 2})
+	heap.Push(&q, &item{taskID: "high-second", priority: 5, seq: 3})
+
+	want := []string{"high-first", "high-second", "low"}
+	for _, id := range want {
+		got, _ := heap.Pop(&q).(*item)
+		if got.taskID != id {
+			t.Errorf("Pop() = %q, want %q", got.taskID, id)
+		}
+	}
+}
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	base := 100 * time.Millisecond
+	maxDelay := time.Second
+
+	cases := map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		10: maxDelay,
+	}
+
+	for attempt, want := range cases {
+		if got := backoff(attempt, base, maxDelay); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestIsTransientClassifiesKnownPatterns(t *testing.T) {
+	if !isTransient("write: Connection reset by peer") {
+		t.Error("expected connection reset to be classified as transient")
+	}
+
+	if !isTransient("av_interleaved_write_frame(): No space left on device") {
+		t.Error("expected disk-full error to be classified as transient")
+	}
+
+	if isTransient("Unknown encoder 'h264_nvenc'") {
+		t.Error("expected an unrelated ffmpeg error not to be classified as transient")
+	}
+}
+
+func TestPoolRunsTaskToCompletion(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Create(&models.Task{ID: "task-done", OutputFormat: "mp4"}).Error; err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+
+	run := func(taskID string, attempt int) (ProcessHandle, error) {
+		return newFakeHandle(nil), nil
+	}
+
+	p := New(db, run, Options{MaxConcurrent: 1, MaxAttempts: 1, GracePeriod: time.Second})
+	p.Enqueue("task-done", 0)
+
+	waitForStatus(t, db, "task-done", models.StatusCompleted)
+	p.Shutdown(time.Second)
+}
+
+func TestPoolDeadLettersAfterMaxAttempts(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Create(&models.Task{ID: "task-flaky", OutputFormat: "mp4"}).Error; err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+
+	// "connection reset" marks each failure as transient, so the pool must
+	// retry once before giving up once MaxAttempts is reached.
+	run := func(taskID string, attempt int) (ProcessHandle, error) {
+		h := newFakeHandle(errors.New("exit status 1"))
+		h.stderr = "Connection reset by peer"
+
+		return h, nil
+	}
+
+	p := New(db, run, Options{
+		MaxConcurrent: 1,
+		MaxAttempts:   2,
+		BackoffBase:   time.Millisecond,
+		BackoffMax:    10 * time.Millisecond,
+		GracePeriod:   time.Second,
+	})
+
+	p.Enqueue("task-flaky", 0)
+
+	waitForStatus(t, db, "task-flaky", models.StatusDeadLetter)
+	p.Shutdown(time.Second)
+}
+
+func TestPoolCancelQueuedTask(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Create(&models.Task{ID: "task-queued", OutputFormat: "mp4"}).Error; err != nil {
+		t.Fatalf("failed to seed task: %v", err)
+	}
+
+	run := func(taskID string, attempt int) (ProcessHandle, error) {
+		t.Fatal("run should not be called for a task canceled before it started")
+
+		return nil, nil
+	}
+
+	p := New(db, run, Options{})
+	p.Enqueue("task-queued", 0)
+
+	if !p.Cancel("task-queued") {
+		t.Fatal("Cancel() = false, want true")
+	}
+
+	waitForStatus(t, db, "task-queued", models.StatusCanceled)
+}